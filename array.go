@@ -0,0 +1,246 @@
+package bitfinex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// UnmarshalArray parses a v2 positional array response, e.g. [ID, MTS, AMOUNT, PRICE],
+// and stores the result in the value pointed to by v.
+//
+// Struct fields are mapped to array indexes with the bfx tag:
+//
+//	type Trade struct {
+//		ID     int     `bfx:"0"`
+//		MTS    int64   `bfx:"1"`
+//		Amount float64 `bfx:"2"`
+//		Price  float64 `bfx:"3"`
+//	}
+//
+// Fields without the tag (or tagged "-") and unexported fields are left
+// untouched, nulls and indexes past the end of the array decode to zero
+// values and elements without a matching field are ignored, so appended
+// fields do not break decoding. Slices of structs are decoded from arrays of arrays, nested
+// structs from nested arrays and interface{} fields receive the raw value.
+func UnmarshalArray(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep IDs and millisecond timestamps exact
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	return DecodeArray(raw, v)
+}
+
+// DecodeArray stores an already unmarshalled array (as produced by
+// encoding/json) in the value pointed to by v, following the rules of
+// UnmarshalArray.
+func DecodeArray(raw interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("bitfinex: DecodeArray requires a non-nil pointer, got %T", v)
+	}
+
+	return decodeValue(raw, rv.Elem(), "")
+}
+
+// arrayField maps a struct field to the array index it is decoded from.
+type arrayField struct {
+	field int
+	index int
+}
+
+var arrayFieldsCache = struct {
+	sync.RWMutex
+	fields map[reflect.Type][]arrayField
+}{fields: make(map[reflect.Type][]arrayField)}
+
+func arrayFields(t reflect.Type) ([]arrayField, error) {
+	arrayFieldsCache.RLock()
+	fields, ok := arrayFieldsCache.fields[t]
+	arrayFieldsCache.RUnlock()
+	if ok {
+		return fields, nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("bfx")
+		if tag == "" || tag == "-" || t.Field(i).PkgPath != "" { // Unexported fields cannot be set
+			continue
+		}
+
+		index, err := strconv.Atoi(tag)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("bitfinex: invalid bfx tag %q on %s.%s", tag, t.Name(), t.Field(i).Name)
+		}
+
+		fields = append(fields, arrayField{i, index})
+	}
+
+	arrayFieldsCache.Lock()
+	arrayFieldsCache.fields[t] = fields
+	arrayFieldsCache.Unlock()
+
+	return fields, nil
+}
+
+func decodeValue(raw interface{}, v reflect.Value, path string) error {
+	if raw == nil { // null
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(raw))
+		return nil
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(raw, v.Elem(), path)
+
+	case reflect.Struct:
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return decodeError(raw, v, path)
+		}
+
+		fields, err := arrayFields(v.Type())
+		if err != nil {
+			return err
+		}
+
+		for _, f := range fields {
+			if f.index >= len(arr) {
+				field := v.Field(f.field)
+				field.Set(reflect.Zero(field.Type()))
+				continue
+			}
+
+			err = decodeValue(arr[f.index], v.Field(f.field), path+"."+v.Type().Field(f.field).Name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return decodeError(raw, v, path)
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(arr), len(arr))
+		for i, e := range arr {
+			err := decodeValue(e, slice.Index(i), path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+
+	case reflect.String:
+		switch r := raw.(type) {
+		case string:
+			v.SetString(r)
+		case json.Number:
+			v.SetString(r.String())
+		case float64:
+			v.SetString(strconv.FormatFloat(r, 'f', -1, 64))
+		case bool:
+			v.SetString(strconv.FormatBool(r))
+		default:
+			return decodeError(raw, v, path)
+		}
+		return nil
+
+	case reflect.Bool:
+		switch r := raw.(type) {
+		case bool:
+			v.SetBool(r)
+			return nil
+		case string:
+			v.SetBool(r == "1" || strings.ToLower(r) == "true")
+			return nil
+		}
+
+		f, err := rawNumber(raw)
+		if err != nil {
+			return decodeError(raw, v, path)
+		}
+		v.SetBool(f != 0)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := raw.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v.SetInt(i)
+				return nil
+			}
+		}
+
+		f, err := rawNumber(raw)
+		if err != nil {
+			return decodeError(raw, v, path)
+		}
+		v.SetInt(int64(f))
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := raw.(json.Number); ok {
+			if i, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+				v.SetUint(i)
+				return nil
+			}
+		}
+
+		f, err := rawNumber(raw)
+		if err != nil || f < 0 {
+			return decodeError(raw, v, path)
+		}
+		v.SetUint(uint64(f))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		f, err := rawNumber(raw)
+		if err != nil {
+			return decodeError(raw, v, path)
+		}
+		v.SetFloat(f)
+		return nil
+	}
+
+	return decodeError(raw, v, path)
+}
+
+// rawNumber converts numbers, including ones sent as strings, to float64.
+func rawNumber(raw interface{}) (float64, error) {
+	switch r := raw.(type) {
+	case float64:
+		return r, nil
+	case json.Number:
+		return r.Float64()
+	case string:
+		return strconv.ParseFloat(r, 64)
+	}
+
+	return 0, fmt.Errorf("bitfinex: %v is not a number", raw)
+}
+
+func decodeError(raw interface{}, v reflect.Value, path string) error {
+	if path == "" {
+		path = v.Type().String()
+	}
+
+	return fmt.Errorf("bitfinex: cannot decode %T %v into %s (%s)", raw, raw, path, v.Type())
+}
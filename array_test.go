@@ -0,0 +1,132 @@
+package bitfinex
+
+import (
+	"testing"
+)
+
+func TestUnmarshalArray(t *testing.T) {
+	// Fields past TRADE_ID are appended by newer API versions and must be ignored
+	trade := Trade{}
+	err := UnmarshalArray([]byte(`[401597395, 1574694475039, 0.005, 7244.9, "appended"]`), &trade)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if trade.ID != 401597395 || trade.Created != 1574694475039 || trade.Amount != 0.005 || trade.Price != 7244.9 {
+		t.Errorf("Failed: unexpected trade %+v", trade)
+		return
+	}
+}
+
+func TestUnmarshalArrayNulls(t *testing.T) {
+	// Nulls and missing trailing fields decode to zero values
+	offer := FundingOffer{Renew: true}
+	err := UnmarshalArray([]byte(`[41238905, "fUSD", 1573912039000, 1573912039000, 1000, 1000, "LIMIT", null, null, 0, "ACTIVE", null, null, null, 0.0024, 2, 0, 0, null, null]`), &offer)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if offer.ID != 41238905 || offer.Symbol != "fUSD" || offer.Status != "ACTIVE" || offer.Rate != 0.0024 || offer.Period != 2 || offer.Renew {
+		t.Errorf("Failed: unexpected offer %+v", offer)
+		return
+	}
+
+	wallet := Wallet{}
+	err = UnmarshalArray([]byte(`["exchange", "BTC", 1.5]`), &wallet)
+	if err != nil || wallet.Balance != 1.5 || wallet.Available != 0 {
+		t.Errorf("Failed: unexpected wallet %+v (%v)", wallet, err)
+		return
+	}
+
+	// Missing fields of a reused struct are reset, not left stale
+	wallet = Wallet{Type: "margin", Available: 2, LastChange: "Transfer"}
+	err = UnmarshalArray([]byte(`["exchange", "BTC", 1.5]`), &wallet)
+	if err != nil || wallet.Type != "exchange" || wallet.Available != 0 || wallet.LastChange != "" {
+		t.Errorf("Failed: unexpected wallet %+v (%v)", wallet, err)
+		return
+	}
+}
+
+func TestUnmarshalArrayUnexported(t *testing.T) {
+	// Unexported fields are skipped, as by encoding/json
+	v := struct {
+		ID     int     `bfx:"0"`
+		amount float64 `bfx:"1"`
+	}{amount: 2}
+
+	err := UnmarshalArray([]byte(`[5, 1.5]`), &v)
+	if err != nil || v.ID != 5 || v.amount != 2 {
+		t.Errorf("Failed: unexpected value %+v (%v)", v, err)
+	}
+}
+
+func TestUnmarshalArrayNested(t *testing.T) {
+	// Notification wrapping an array of orders
+	n := Notification{}
+	err := UnmarshalArray([]byte(`[1567590617439, "on-req", null, null, [[30630788061, null, 1567590617439, "tBTCUSD", 1567590617439, 1567590617439, 0.001, 0.001, "LIMIT", null, null, null, 4096, "ACTIVE", null, null, 15, 0, 0, 0, null, null, null, 0, null, null]], null, "SUCCESS", "Submitting 1 orders."]`), &n)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	orders := Orders{}
+	err = DecodeArray(n.Data, &orders)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if n.Status != "SUCCESS" || len(orders) != 1 || orders[0].ID != 30630788061 || orders[0].Flags != 4096 || orders[0].Price != 15 {
+		t.Errorf("Failed: unexpected notification %+v with orders %+v", n, orders)
+		return
+	}
+
+	// Nested structs and pointers
+	v := struct {
+		Symbol string    `bfx:"0"`
+		Trade  *Trade    `bfx:"1"`
+		Trades []Trade   `bfx:"2"`
+		Skip   string    `bfx:"-"`
+		Raw    []float64 `bfx:"3"`
+	}{Skip: "untouched"}
+	err = UnmarshalArray([]byte(`["tBTCUSD", [1, 2, 3, 4], [[5, 6, 7, 8]], [1.5, "2.5"]]`), &v)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if v.Symbol != "tBTCUSD" || v.Trade == nil || v.Trade.Price != 4 || len(v.Trades) != 1 || v.Trades[0].ID != 5 || v.Skip != "untouched" || len(v.Raw) != 2 || v.Raw[1] != 2.5 {
+		t.Errorf("Failed: unexpected value %+v", v)
+		return
+	}
+}
+
+func TestUnmarshalArrayErrors(t *testing.T) {
+	// Objects cannot be decoded into structs
+	trade := Trade{}
+	if UnmarshalArray([]byte(`{"id": 1}`), &trade) == nil {
+		t.Error("Failed")
+		return
+	}
+
+	// Mismatched types must return an error
+	if UnmarshalArray([]byte(`["one", 2, 3, 4]`), &trade) == nil {
+		t.Error("Failed")
+		return
+	}
+
+	// Non-pointer destinations must return an error
+	if UnmarshalArray([]byte(`[1, 2, 3, 4]`), trade) == nil {
+		t.Error("Failed")
+		return
+	}
+
+	// v2 error messages are extracted
+	err := errorV2([]byte(`["error", 10020, "symbol: invalid"]`), nil)
+	if err == nil || err.Error() != "API: symbol: invalid" {
+		t.Error("Failed")
+		return
+	}
+}
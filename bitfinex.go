@@ -1,5 +1,5 @@
 // TODO: Public: Orderbook, Trades, Lends, Symbols, Symbols Details
-// TODO: Authenticated: New deposit, New order, Multiple new orders, Cancel order, Cancel multiple orders, Cancel all active orders, Replace order, Order status, Active Orders, Active Positions, Claim position, Past trades, Offer status, Active Swaps used in a margin position, Balance history, Close swap, Account informations, Margin informations
+// TODO: Authenticated: New order, Multiple new orders, Cancel order, Cancel multiple orders, Replace order, Order status, Active Orders, Active Positions, Claim position, Past trades, Offer status, Active Swaps used in a margin position, Balance history, Close swap, Account informations, Margin informations
 
 package bitfinex
 
@@ -25,12 +25,71 @@ const (
 	LEND = "lend"
 	// BORROW ...
 	BORROW = "borrow"
+	// FRR is the offer rate which places an offer at the Flash Return Rate
+	FRR = 0.0
 )
 
+// WalletType ...
+type WalletType string
+
+const (
+	// TRADING ...
+	TRADING WalletType = "trading"
+	// EXCHANGE ...
+	EXCHANGE WalletType = "exchange"
+	// DEPOSIT ...
+	DEPOSIT WalletType = "deposit"
+)
+
+// WithdrawMethod ...
+type WithdrawMethod string
+
+const (
+	// BITCOIN ...
+	BITCOIN WithdrawMethod = "bitcoin"
+	// LITECOIN ...
+	LITECOIN WithdrawMethod = "litecoin"
+	// ETHEREUM ...
+	ETHEREUM WithdrawMethod = "ethereum"
+	// ZCASH ...
+	ZCASH WithdrawMethod = "zcash"
+	// MONERO ...
+	MONERO WithdrawMethod = "monero"
+	// WIRE ...
+	WIRE WithdrawMethod = "wire"
+)
+
+// ErrUnknownWithdrawMethod is returned by Withdraw for methods other than the
+// WithdrawMethod constants.
+var ErrUnknownWithdrawMethod = errors.New("API: Unknown withdrawal method")
+
+// ErrWithdrawNotAllowed is returned by Withdraw when the destination is not
+// present in API.WithdrawAllowList.
+var ErrWithdrawNotAllowed = errors.New("API: Withdrawal destination is not in the allow-list")
+
+// ErrMaintenance is returned instead of sending authenticated mutations while
+// the platform is in maintenance, if platform status checks are enabled.
+var ErrMaintenance = errors.New("API: Platform is in maintenance")
+
 // API structure stores Bitfinex API credentials
 type API struct {
 	APIKey    string
 	APISecret string
+
+	// WithdrawAllowList holds crypto addresses and wire account numbers
+	// Withdraw is permitted to send funds to. Destinations with a payment ID
+	// / memo are listed as "address:paymentID", as a shared address belongs
+	// to whoever owns the memo. Withdraw refuses every request while the
+	// list is empty.
+	WithdrawAllowList []string
+
+	// CheckPlatformStatus makes offer, transfer and withdrawal requests query
+	// PlatformStatus first and return ErrMaintenance during maintenance.
+	// CancelAllOrders and the Watchdog are not gated.
+	CheckPlatformStatus bool
+
+	// URL is the API URL requests are sent to, APIURL if empty.
+	URL string
 }
 
 // ErrorMessage ...
@@ -156,6 +215,186 @@ type Credit struct {
 // Credits ...
 type Credits []Credit
 
+// DepositAddress ...
+type DepositAddress struct {
+	Result      string `json:"result"`       // "success" or "error"
+	Method      string `json:"method"`       // Deposit method, e.g. "bitcoin", "litecoin", "ethereum"
+	Currency    string `json:"currency"`     // Currency the address accepts
+	Address     string `json:"address"`      // The deposit address, or the payment ID / memo if AddressPool is set
+	AddressPool string `json:"address_pool"` // Shared address for methods which route deposits by payment ID / memo
+}
+
+// PaymentID returns the payment ID / memo which has to accompany deposits to
+// a shared address, or an empty string if the method does not use one.
+func (d DepositAddress) PaymentID() string {
+	if d.AddressPool == "" {
+		return ""
+	}
+	return d.Address
+}
+
+// DestinationAddress returns the address funds have to be sent to.
+func (d DepositAddress) DestinationAddress() string {
+	if d.AddressPool != "" {
+		return d.AddressPool
+	}
+	return d.Address
+}
+
+// Transfer ...
+type Transfer struct {
+	Status  string `json:"status"`  // "success" or "error"
+	Message string `json:"message"` // Success or error message
+}
+
+// WithdrawRequest ...
+type WithdrawRequest struct {
+	Method WithdrawMethod // Withdrawal method
+	Wallet WalletType     // Wallet to withdraw from
+	Amount float64        // Amount to withdraw
+
+	// Crypto withdrawals
+	Address   string // Destination address
+	PaymentID string // Payment ID / memo, for methods which require one
+
+	// Wire withdrawals
+	AccountName   string // Account name
+	AccountNumber string // Account number, checked against the allow-list
+	BankName      string // Bank name
+	BankAddress   string // Bank address
+	BankCity      string // Bank city
+	BankCountry   string // Bank country
+	Swift         string // SWIFT code
+	Detail        string // Message to beneficiary
+	ExpressWire   bool   // Express wire (higher fee)
+}
+
+// Destination returns the address or account number funds would be sent to,
+// as checked against the allow-list: "address:paymentID" if a payment ID is
+// set.
+func (w WithdrawRequest) Destination() string {
+	if strings.EqualFold(string(w.Method), string(WIRE)) {
+		return w.AccountNumber
+	}
+	if w.PaymentID != "" {
+		return w.Address + ":" + w.PaymentID
+	}
+	return w.Address
+}
+
+// normalize lower-cases the method, which must be known, and clears the
+// fields other methods use, so only the destination checked against the
+// allow-list is sent.
+func (w WithdrawRequest) normalize() (WithdrawRequest, error) {
+	w.Method = WithdrawMethod(strings.ToLower(string(w.Method)))
+
+	switch w.Method {
+	case BITCOIN, LITECOIN, ETHEREUM, ZCASH, MONERO:
+		w.AccountName, w.AccountNumber = "", ""
+		w.BankName, w.BankAddress, w.BankCity, w.BankCountry = "", "", "", ""
+		w.Swift, w.Detail, w.ExpressWire = "", "", false
+	case WIRE:
+		w.Address, w.PaymentID = "", ""
+	default:
+		return w, ErrUnknownWithdrawMethod
+	}
+
+	return w, nil
+}
+
+// Withdrawal ...
+type Withdrawal struct {
+	Status       string `json:"status"`        // "success" or "error"
+	Message      string `json:"message"`       // Success or error message
+	WithdrawalID int    `json:"withdrawal_id"` // ID of the withdrawal, 0 if unsuccessful
+}
+
+// Permission ...
+type Permission struct {
+	Read  bool `json:"read"`  // Key can read this scope
+	Write bool `json:"write"` // Key can modify this scope
+}
+
+// KeyPermissions ...
+type KeyPermissions struct {
+	Account   Permission `json:"account"`   // Account information
+	History   Permission `json:"history"`   // Balance, movements and trade history
+	Orders    Permission `json:"orders"`    // Orders
+	Positions Permission `json:"positions"` // Margin positions
+	Funding   Permission `json:"funding"`   // Margin funding offers and credits
+	Wallets   Permission `json:"wallets"`   // Wallet balances and transfers
+	Withdraw  Permission `json:"withdraw"`  // Withdrawals
+}
+
+// AutoRenew ...
+type AutoRenew struct {
+	Currency  string  `bfx:"0"` // The currency name of the offer.
+	Period    int     `bfx:"1"` // The number of days of the offer.
+	Rate      float64 `bfx:"2"` // The rate the offer is renewed at (in % per 365 days), 0 for FRR.
+	Threshold float64 `bfx:"3"` // Maximum amount renewed
+}
+
+// FundingOffer ...
+type FundingOffer struct {
+	ID             int     `bfx:"0"`  // Offer ID
+	Symbol         string  `bfx:"1"`  // Funding symbol, e.g. "fUSD"
+	Created        int64   `bfx:"2"`  // Millisecond timestamp of creation
+	Updated        int64   `bfx:"3"`  // Millisecond timestamp of last update
+	Amount         float64 `bfx:"4"`  // Amount still offered, positive for lend, negative for borrow
+	OriginalAmount float64 `bfx:"5"`  // Amount the offer was submitted for
+	Type           string  `bfx:"6"`  // "LIMIT", "FRRDELTAVAR" or "FRRDELTAFIX"
+	Flags          int     `bfx:"9"`  // Offer flags
+	Status         string  `bfx:"10"` // "ACTIVE", "EXECUTED", "PARTIALLY FILLED" or "CANCELED"
+	Rate           float64 `bfx:"14"` // Rate per day, e.g. 0.0002 is 0.02% per day
+	Period         int     `bfx:"15"` // Period in days
+	Notify         bool    `bfx:"16"` // Notify on execution
+	Hidden         bool    `bfx:"17"` // Hidden offer
+	Renew          bool    `bfx:"19"` // Auto-renew when the loan is returned
+}
+
+// FundingOffers ...
+type FundingOffers []FundingOffer
+
+// FundingCredit ...
+type FundingCredit struct {
+	ID           int     `bfx:"0"`  // Credit ID
+	Symbol       string  `bfx:"1"`  // Funding symbol, e.g. "fUSD"
+	Side         int     `bfx:"2"`  // 1 if you are the lender, 0 if both, -1 if you are the borrower
+	Created      int64   `bfx:"3"`  // Millisecond timestamp of creation
+	Updated      int64   `bfx:"4"`  // Millisecond timestamp of last update
+	Amount       float64 `bfx:"5"`  // Amount of the credit
+	Flags        int     `bfx:"6"`  // Credit flags
+	Status       string  `bfx:"7"`  // "ACTIVE" or "CLOSED (used)", "CLOSED (expired)", ...
+	Rate         float64 `bfx:"11"` // Rate per day, e.g. 0.0002 is 0.02% per day
+	Period       int     `bfx:"12"` // Period in days
+	Opened       int64   `bfx:"13"` // Millisecond timestamp the credit was opened
+	LastPayout   int64   `bfx:"14"` // Millisecond timestamp of the last interest payout
+	Notify       bool    `bfx:"15"` // Notify on status change
+	Hidden       bool    `bfx:"16"` // Hidden credit
+	Renew        bool    `bfx:"18"` // Auto-renew when the loan is returned
+	RateReal     float64 `bfx:"19"` // Effective rate, differs from Rate for FRR credits
+	NoClose      bool    `bfx:"20"` // Credit is not closed when the position is closed
+	PositionPair string  `bfx:"21"` // Trading pair of the position using the credit
+}
+
+// FundingCredits ...
+type FundingCredits []FundingCredit
+
+// FundingTrade ...
+type FundingTrade struct {
+	ID      int     `bfx:"0"` // Trade ID
+	Symbol  string  `bfx:"1"` // Funding symbol, e.g. "fUSD"
+	Created int64   `bfx:"2"` // Millisecond timestamp of execution
+	OfferID int     `bfx:"3"` // ID of the offer which was executed
+	Amount  float64 `bfx:"4"` // Amount executed, positive for lend, negative for borrow
+	Rate    float64 `bfx:"5"` // Rate per day, e.g. 0.0002 is 0.02% per day
+	Period  int     `bfx:"6"` // Period in days
+	Maker   bool    `bfx:"7"` // Your offer was the maker
+}
+
+// FundingTrades ...
+type FundingTrades []FundingTrade
+
 // New returns a new Bitfinex API instance
 func New(key, secret string) (api *API) {
 	api = &API{
@@ -174,7 +413,7 @@ func New(key, secret string) (api *API) {
 func (api *API) Ticker(symbol string) (ticker Ticker, err error) {
 	symbol = strings.ToLower(symbol)
 
-	body, err := api.get("/v2/pubticker/" + symbol)
+	body, err := api.get("/v1/pubticker/" + symbol)
 	if err != nil {
 		return
 	}
@@ -198,7 +437,7 @@ func (api *API) Ticker(symbol string) (ticker Ticker, err error) {
 func (api *API) Stats(symbol string) (stats Stats, err error) {
 	symbol = strings.ToLower(symbol)
 
-	body, err := api.get("/v2/stats/" + symbol)
+	body, err := api.get("/v1/stats/" + symbol)
 	if err != nil {
 		return
 	}
@@ -222,7 +461,7 @@ func (api *API) Stats(symbol string) (stats Stats, err error) {
 func (api *API) Orderbook(symbol string, limitBids, limitAsks, group int) (orderbook Orderbook, err error) {
 	symbol = strings.ToLower(symbol)
 
-	body, err := api.get("/v2/book/" + symbol + "?limit_bids=" + strconv.Itoa(limitBids) + "&limit_asks=" + strconv.Itoa(limitAsks) + "&group=" + strconv.Itoa(group))
+	body, err := api.get("/v1/book/" + symbol + "?limit_bids=" + strconv.Itoa(limitBids) + "&limit_asks=" + strconv.Itoa(limitAsks) + "&group=" + strconv.Itoa(group))
 	if err != nil {
 		return
 	}
@@ -239,7 +478,7 @@ func (api *API) Orderbook(symbol string, limitBids, limitAsks, group int) (order
 func (api *API) Lendbook(currency string, limitBids, limitAsks int) (lendbook Lendbook, err error) {
 	currency = strings.ToLower(currency)
 
-	body, err := api.get("/v2/lendbook/" + currency + "?limit_bids=" + strconv.Itoa(limitBids) + "&limit_asks=" + strconv.Itoa(limitAsks))
+	body, err := api.get("/v1/lendbook/" + currency + "?limit_bids=" + strconv.Itoa(limitBids) + "&limit_asks=" + strconv.Itoa(limitAsks))
 	if err != nil {
 		return
 	}
@@ -272,7 +511,7 @@ func (api *API) WalletBalances() (wallet WalletBalances, err error) {
 		URL   string `json:"request"`
 		Nonce string `json:"nonce"`
 	}{
-		"/v2/balances",
+		"/v1/balances",
 		strconv.FormatInt(time.Now().UnixNano(), 10),
 	}
 
@@ -313,7 +552,7 @@ func (api *API) MyTrades(symbol string, timestamp string, limitTrades int) (mytr
 		Timestamp   string `json:"timestamp"`
 		LimitTrades int    `json:"limit_trades"`
 	}{
-		URL:         "/v2/mytrades",
+		URL:         "/v1/mytrades",
 		Nonce:       strconv.FormatInt(time.Now().UnixNano(), 10),
 		Symbol:      symbol,
 		Timestamp:   timestamp,
@@ -341,12 +580,22 @@ func (api *API) MyTrades(symbol string, timestamp string, limitTrades int) (mytr
 
 // CancelOffer cancel an offer give its id.
 func (api *API) CancelOffer(id int) (err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	return api.cancelOffer(id)
+}
+
+// cancelOffer cancels an offer without checking the platform status.
+func (api *API) cancelOffer(id int) (err error) {
 	request := struct {
 		URL     string `json:"request"`
 		Nonce   string `json:"nonce"`
 		OfferID int    `json:"offer_id"`
 	}{
-		"/v2/offer/cancel",
+		"/v1/offer/cancel",
 		strconv.FormatInt(time.Now().UnixNano(), 10),
 		id,
 	}
@@ -386,7 +635,7 @@ func (api *API) ActiveCredits() (credits Credits, err error) {
 		URL   string `json:"request"`
 		Nonce string `json:"nonce"`
 	}{
-		"/v2/credits",
+		"/v1/credits",
 		strconv.FormatInt(time.Now().UnixNano(), 10),
 	}
 
@@ -416,7 +665,7 @@ func (api *API) ActiveOffers() (offers Offers, err error) {
 		URL   string `json:"request"`
 		Nonce string `json:"nonce"`
 	}{
-		"/v2/offers",
+		"/v1/offers",
 		strconv.FormatInt(time.Now().UnixNano(), 10),
 	}
 
@@ -440,13 +689,67 @@ func (api *API) ActiveOffers() (offers Offers, err error) {
 	return
 }
 
+// CancelAllOrders cancels all your active orders. It is not gated by
+// CheckPlatformStatus: as the emergency stop of the Watchdog it must not be
+// held back by a failing or stale status check, the request simply fails if
+// the platform does not accept it.
+func (api *API) CancelAllOrders() (err error) {
+	request := struct {
+		URL   string `json:"request"`
+		Nonce string `json:"nonce"`
+	}{
+		"/v1/order/cancel/all",
+		strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+
+	body, err := api.post(request.URL, request)
+	if err != nil {
+		return
+	}
+
+	tmpResult := struct {
+		Result string `json:"result"`
+	}{}
+
+	err = json.Unmarshal(body, &tmpResult)
+	if err != nil || tmpResult.Result == "" { // Failed to unmarshal expected message
+		// Attempt to unmarshal the error message
+		errorMessage := ErrorMessage{}
+		err = json.Unmarshal(body, &errorMessage)
+		if err != nil { // Not expected message and not expected error, bailing...
+			return
+		}
+
+		return errors.New("API: " + errorMessage.Message)
+	}
+
+	return
+}
+
+// PlatformStatus returns true if the platform is operative and false if it
+// is in maintenance.
+func (api *API) PlatformStatus() (operative bool, err error) {
+	return api.v2().PlatformStatus()
+}
+
+// Tickers returns the tickers of many symbols in a single request, see
+// APIv2.Tickers.
+func (api *API) Tickers(symbols ...string) (tickers Tickers, err error) {
+	return api.v2().Tickers(symbols...)
+}
+
 // NewOffer submits a new offer.
 // currency (string): The name of the currency.
 // amount (decimal): Offer size: how much to lend or borrow.
-// rate (decimal): Rate to lend or borrow at. In percentage per 365 days.
+// rate (decimal): Rate to lend or borrow at. In percentage per 365 days, FRR for the Flash Return Rate.
 // period (integer): Number of days of the loan (in days)
 // direction (string): Either "lend" or "loan".
 func (api *API) NewOffer(currency string, amount, rate float64, period int, direction string) (offer Offer, err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
 	currency = strings.ToUpper(currency)
 	direction = strings.ToLower(direction)
 
@@ -459,7 +762,7 @@ func (api *API) NewOffer(currency string, amount, rate float64, period int, dire
 		Period    int     `json:"period"`
 		Direction string  `json:"direction"`
 	}{
-		"/v2/offer/new",
+		"/v1/offer/new",
 		strconv.FormatInt(time.Now().UnixNano(), 10),
 		currency,
 		amount,
@@ -488,6 +791,331 @@ func (api *API) NewOffer(currency string, amount, rate float64, period int, dire
 	return
 }
 
+// NewFRROffer submits a new offer at the Flash Return Rate, which follows the
+// market instead of staying at a fixed rate.
+// currency (string): The name of the currency.
+// amount (decimal): Offer size: how much to lend or borrow.
+// period (integer): Number of days of the loan (in days)
+// direction (string): Either "lend" or "loan".
+func (api *API) NewFRROffer(currency string, amount float64, period int, direction string) (offer Offer, err error) {
+	return api.NewOffer(currency, amount, FRR, period, direction)
+}
+
+// NewDepositAddress returns a deposit address for the given method and wallet.
+// method (string): Method of deposit, e.g. "bitcoin", "litecoin", "ethereum".
+// wallet (WalletType): Wallet to deposit in.
+// renew (bool): If true, a new address is generated even if one already exists.
+func (api *API) NewDepositAddress(method string, wallet WalletType, renew bool) (address DepositAddress, err error) {
+	method = strings.ToLower(method)
+
+	renewInt := 0
+	if renew {
+		renewInt = 1
+	}
+
+	request := struct {
+		URL        string `json:"request"`
+		Nonce      string `json:"nonce"`
+		Method     string `json:"method"`
+		WalletName string `json:"wallet_name"`
+		Renew      int    `json:"renew"`
+	}{
+		"/v1/deposit/new",
+		strconv.FormatInt(time.Now().UnixNano(), 10),
+		method,
+		string(wallet),
+		renewInt,
+	}
+
+	body, err := api.post(request.URL, request)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, &address)
+	if err != nil || address.Result != "success" { // Failed to unmarshal expected message
+		// Attempt to unmarshal the error message
+		errorMessage := ErrorMessage{}
+		err = json.Unmarshal(body, &errorMessage)
+		if err != nil { // Not expected message and not expected error, bailing...
+			return
+		}
+
+		if errorMessage.Message == "" && address.Address != "" {
+			// {"result": "error", "address": "<reason>"}
+			errorMessage.Message = address.Address
+		}
+
+		return address, errors.New("API: " + errorMessage.Message)
+	}
+
+	return
+}
+
+// Transfer moves available balances between your wallets.
+// amount (decimal): Amount to transfer.
+// currency (string): Currency of funds to transfer.
+// from (WalletType): Wallet to transfer from.
+// to (WalletType): Wallet to transfer to.
+func (api *API) Transfer(amount float64, currency string, from, to WalletType) (transfer Transfer, err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	currency = strings.ToUpper(currency)
+
+	request := struct {
+		URL        string  `json:"request"`
+		Nonce      string  `json:"nonce"`
+		Amount     float64 `json:"amount,string"`
+		Currency   string  `json:"currency"`
+		WalletFrom string  `json:"walletfrom"`
+		WalletTo   string  `json:"walletto"`
+	}{
+		"/v1/transfer",
+		strconv.FormatInt(time.Now().UnixNano(), 10),
+		amount,
+		currency,
+		string(from),
+		string(to),
+	}
+
+	body, err := api.post(request.URL, request)
+	if err != nil {
+		return
+	}
+
+	tmpTransfers := []Transfer{}
+	err = json.Unmarshal(body, &tmpTransfers)
+	if err != nil || len(tmpTransfers) == 0 { // Failed to unmarshal expected message
+		// Attempt to unmarshal the error message
+		errorMessage := ErrorMessage{}
+		err = json.Unmarshal(body, &errorMessage)
+		if err != nil { // Not expected message and not expected error, bailing...
+			return
+		}
+
+		return transfer, errors.New("API: " + errorMessage.Message)
+	}
+
+	transfer = tmpTransfers[0]
+	if transfer.Status != "success" {
+		return transfer, errors.New("API: " + transfer.Message)
+	}
+
+	return
+}
+
+// Withdraw requests a withdrawal from one of your wallets. The destination
+// must be present in api.WithdrawAllowList, otherwise ErrWithdrawNotAllowed
+// is returned and nothing is sent to the API. Methods are matched regardless
+// of case, ErrUnknownWithdrawMethod is returned for unknown methods.
+func (api *API) Withdraw(withdraw WithdrawRequest) (withdrawal Withdrawal, err error) {
+	withdraw, err = withdraw.normalize()
+	if err != nil {
+		return
+	}
+
+	if !api.withdrawAllowed(withdraw.Destination()) {
+		return withdrawal, ErrWithdrawNotAllowed
+	}
+
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	expressWire := 0
+	if withdraw.ExpressWire {
+		expressWire = 1
+	}
+
+	request := struct {
+		URL           string  `json:"request"`
+		Nonce         string  `json:"nonce"`
+		WithdrawType  string  `json:"withdraw_type"`
+		Wallet        string  `json:"walletselected"`
+		Amount        float64 `json:"amount,string"`
+		Address       string  `json:"address,omitempty"`
+		PaymentID     string  `json:"payment_id,omitempty"`
+		AccountName   string  `json:"account_name,omitempty"`
+		AccountNumber string  `json:"account_number,omitempty"`
+		BankName      string  `json:"bank_name,omitempty"`
+		BankAddress   string  `json:"bank_address,omitempty"`
+		BankCity      string  `json:"bank_city,omitempty"`
+		BankCountry   string  `json:"bank_country,omitempty"`
+		Swift         string  `json:"swift,omitempty"`
+		Detail        string  `json:"detail_payment,omitempty"`
+		ExpressWire   int     `json:"expressWire,omitempty"`
+	}{
+		URL:           "/v1/withdraw",
+		Nonce:         strconv.FormatInt(time.Now().UnixNano(), 10),
+		WithdrawType:  string(withdraw.Method),
+		Wallet:        string(withdraw.Wallet),
+		Amount:        withdraw.Amount,
+		Address:       withdraw.Address,
+		PaymentID:     withdraw.PaymentID,
+		AccountName:   withdraw.AccountName,
+		AccountNumber: withdraw.AccountNumber,
+		BankName:      withdraw.BankName,
+		BankAddress:   withdraw.BankAddress,
+		BankCity:      withdraw.BankCity,
+		BankCountry:   withdraw.BankCountry,
+		Swift:         withdraw.Swift,
+		Detail:        withdraw.Detail,
+		ExpressWire:   expressWire,
+	}
+
+	body, err := api.post(request.URL, request)
+	if err != nil {
+		return
+	}
+
+	tmpWithdrawals := []Withdrawal{}
+	err = json.Unmarshal(body, &tmpWithdrawals)
+	if err != nil || len(tmpWithdrawals) == 0 { // Failed to unmarshal expected message
+		// Attempt to unmarshal the error message
+		errorMessage := ErrorMessage{}
+		err = json.Unmarshal(body, &errorMessage)
+		if err != nil { // Not expected message and not expected error, bailing...
+			return
+		}
+
+		return withdrawal, errors.New("API: " + errorMessage.Message)
+	}
+
+	withdrawal = tmpWithdrawals[0]
+	if withdrawal.Status != "success" {
+		return withdrawal, errors.New("API: " + withdrawal.Message)
+	}
+
+	return
+}
+
+// KeyPermissions returns the read/write permissions granted to your API key.
+func (api *API) KeyPermissions() (permissions KeyPermissions, err error) {
+	request := struct {
+		URL   string `json:"request"`
+		Nonce string `json:"nonce"`
+	}{
+		"/v1/key_info",
+		strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+
+	body, err := api.post(request.URL, request)
+	if err != nil {
+		return
+	}
+
+	tmpPermissions := struct {
+		KeyPermissions
+		Message string `json:"message"` // Returned only on error
+	}{}
+
+	err = json.Unmarshal(body, &tmpPermissions)
+	if err != nil {
+		return
+	}
+
+	if tmpPermissions.Message != "" {
+		return permissions, errors.New("API: " + tmpPermissions.Message)
+	}
+
+	permissions = tmpPermissions.KeyPermissions
+	return
+}
+
+// SetAutoRenew enables funding auto-renew for the currency: available funds in
+// the deposit wallet are offered again as soon as loans are returned.
+// currency (string): The name of the currency.
+// amount (decimal): Maximum amount to keep offered.
+// rate (decimal): Rate to lend at. In percentage per 365 days, FRR for the Flash Return Rate.
+// period (integer): Number of days of the loan (in days)
+func (api *API) SetAutoRenew(currency string, amount, rate float64, period int) (autoRenew AutoRenew, err error) {
+	return api.autoRenew(1, currency, amount, rate, period)
+}
+
+// DisableAutoRenew disables funding auto-renew for the currency. Like
+// CancelAllOrders, it is not gated by CheckPlatformStatus.
+func (api *API) DisableAutoRenew(currency string) (autoRenew AutoRenew, err error) {
+	return api.autoRenew(0, currency, 0, 0, 0)
+}
+
+// autoRenew sets auto-renew through the v2 API, which takes and returns rates
+// in % per day.
+func (api *API) autoRenew(status int, currency string, amount, rate float64, period int) (autoRenew AutoRenew, err error) {
+	if status != 0 {
+		err = api.checkPlatformStatus()
+		if err != nil {
+			return
+		}
+	}
+
+	request := struct {
+		Status   int     `json:"status"`
+		Currency string  `json:"currency"`
+		Amount   float64 `json:"amount,string,omitempty"`
+		Rate     float64 `json:"rate,string"`
+		Period   int     `json:"period,omitempty"`
+	}{
+		status,
+		strings.ToUpper(currency),
+		amount,
+		rate / 365,
+		period,
+	}
+
+	err = api.v2().notification("/v2/auth/w/funding/auto", request, &autoRenew)
+	autoRenew.Rate *= 365
+	return
+}
+
+// CreditsHistory returns up to limit funding credits closed between since and
+// until, most recent first. Zero since and until leave the range open.
+func (api *API) CreditsHistory(currency string, since, until time.Time, limit int) (credits FundingCredits, err error) {
+	err = api.fundingHistory("credits", currency, timeToMTS(since), timeToMTS(until), limit, &credits)
+	return
+}
+
+// OffersHistory returns up to limit funding offers closed between since and
+// until, most recent first. Zero since and until leave the range open.
+func (api *API) OffersHistory(currency string, since, until time.Time, limit int) (offers FundingOffers, err error) {
+	err = api.fundingHistory("offers", currency, timeToMTS(since), timeToMTS(until), limit, &offers)
+	return
+}
+
+// FundingTrades returns up to limit funding trades executed between since and
+// until, most recent first. Zero since and until leave the range open.
+func (api *API) FundingTrades(currency string, since, until time.Time, limit int) (trades FundingTrades, err error) {
+	err = api.fundingHistory("trades", currency, timeToMTS(since), timeToMTS(until), limit, &trades)
+	return
+}
+
+func (api *API) fundingHistory(kind, currency string, start, end int64, limit int, v interface{}) (err error) {
+	request := struct {
+		Start int64 `json:"start,omitempty"`
+		End   int64 `json:"end,omitempty"`
+		Limit int   `json:"limit,omitempty"`
+	}{
+		start,
+		end,
+		limit,
+	}
+
+	body, err := api.postV2("/v2/auth/r/funding/"+kind+"/"+fundingSymbol(currency)+"/hist", request)
+	if err != nil {
+		return
+	}
+
+	err = UnmarshalArray(body, v)
+	if err != nil { // Failed to unmarshal expected message
+		return errorV2(body, err)
+	}
+
+	return
+}
+
 ///////////////////////////////////////
 // API helper methods
 ///////////////////////////////////////
@@ -531,16 +1159,182 @@ func (api *API) CancelActiveOffersByCurrency(currency string) (err error) {
 	return
 }
 
+// TransferAvailable moves all available balance of the currency between wallets.
+func (api *API) TransferAvailable(currency string, from, to WalletType) (err error) {
+	currency = strings.ToLower(currency)
+
+	balances, err := api.WalletBalances()
+	if err != nil {
+		return
+	}
+
+	balance, ok := balances[WalletKey{string(from), currency}]
+	if !ok || balance.Available <= 0 {
+		return
+	}
+
+	_, err = api.Transfer(balance.Available, currency, from, to)
+	return
+}
+
+// CreditsIterator walks funding credits history page by page.
+type CreditsIterator struct {
+	pager  historyPager
+	credit FundingCredit
+}
+
+// CreditsHistoryIter returns an iterator over all funding credits closed
+// between since and until, fetching limit credits per request.
+func (api *API) CreditsHistoryIter(currency string, since, until time.Time, limit int) *CreditsIterator {
+	return &CreditsIterator{
+		pager: newHistoryPager(since, until, limit, func(start, end int64, limit int) (items []historyItem, err error) {
+			credits, err := api.CreditsHistory(currency, mtsToTime(start), mtsToTime(end), limit)
+			for _, c := range credits {
+				items = append(items, c)
+			}
+			return
+		}),
+	}
+}
+
+// Next advances to the next credit, returning false when done or on error.
+func (it *CreditsIterator) Next() bool {
+	item, ok := it.pager.next()
+	if ok {
+		it.credit = item.(FundingCredit)
+	}
+	return ok
+}
+
+// Credit returns the current credit.
+func (it *CreditsIterator) Credit() FundingCredit {
+	return it.credit
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *CreditsIterator) Err() error {
+	return it.pager.err
+}
+
+// OffersIterator walks funding offers history page by page.
+type OffersIterator struct {
+	pager historyPager
+	offer FundingOffer
+}
+
+// OffersHistoryIter returns an iterator over all funding offers closed
+// between since and until, fetching limit offers per request.
+func (api *API) OffersHistoryIter(currency string, since, until time.Time, limit int) *OffersIterator {
+	return &OffersIterator{
+		pager: newHistoryPager(since, until, limit, func(start, end int64, limit int) (items []historyItem, err error) {
+			offers, err := api.OffersHistory(currency, mtsToTime(start), mtsToTime(end), limit)
+			for _, o := range offers {
+				items = append(items, o)
+			}
+			return
+		}),
+	}
+}
+
+// Next advances to the next offer, returning false when done or on error.
+func (it *OffersIterator) Next() bool {
+	item, ok := it.pager.next()
+	if ok {
+		it.offer = item.(FundingOffer)
+	}
+	return ok
+}
+
+// Offer returns the current offer.
+func (it *OffersIterator) Offer() FundingOffer {
+	return it.offer
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *OffersIterator) Err() error {
+	return it.pager.err
+}
+
+// FundingTradesIterator walks funding trades history page by page.
+type FundingTradesIterator struct {
+	pager historyPager
+	trade FundingTrade
+}
+
+// FundingTradesIter returns an iterator over all funding trades executed
+// between since and until, fetching limit trades per request.
+func (api *API) FundingTradesIter(currency string, since, until time.Time, limit int) *FundingTradesIterator {
+	return &FundingTradesIterator{
+		pager: newHistoryPager(since, until, limit, func(start, end int64, limit int) (items []historyItem, err error) {
+			trades, err := api.FundingTrades(currency, mtsToTime(start), mtsToTime(end), limit)
+			for _, t := range trades {
+				items = append(items, t)
+			}
+			return
+		}),
+	}
+}
+
+// Next advances to the next trade, returning false when done or on error.
+func (it *FundingTradesIterator) Next() bool {
+	item, ok := it.pager.next()
+	if ok {
+		it.trade = item.(FundingTrade)
+	}
+	return ok
+}
+
+// Trade returns the current trade.
+func (it *FundingTradesIterator) Trade() FundingTrade {
+	return it.trade
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *FundingTradesIterator) Err() error {
+	return it.pager.err
+}
+
 ///////////////////////////////////////
 // API query methods
 ///////////////////////////////////////
 
+func (api *API) checkPlatformStatus() (err error) {
+	if !api.CheckPlatformStatus {
+		return
+	}
+
+	operative, err := api.PlatformStatus()
+	if err != nil {
+		return
+	}
+
+	if !operative {
+		return ErrMaintenance
+	}
+
+	return
+}
+
+func (api *API) withdrawAllowed(destination string) bool {
+	if destination == "" {
+		return false
+	}
+
+	for _, a := range api.WithdrawAllowList {
+		if a == destination {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (api *API) get(url string) (body []byte, err error) {
 	client := http.Client{
 		Timeout: time.Duration(30 * time.Second),
 	}
 
-	resp, err := client.Get(APIURL + url)
+	resp, err := client.Get(api.baseURL() + url)
 	if err != nil {
 		return
 	}
@@ -566,7 +1360,7 @@ func (api *API) post(url string, payload interface{}) (body []byte, err error) {
 	signature := hex.EncodeToString(h.Sum(nil))
 
 	// POST
-	req, err := http.NewRequest("POST", APIURL+url, bytes.NewBuffer(payloadJSON))
+	req, err := http.NewRequest("POST", api.baseURL()+url, bytes.NewBuffer(payloadJSON))
 	if err != nil {
 		return
 	}
@@ -588,3 +1382,145 @@ func (api *API) post(url string, payload interface{}) (body []byte, err error) {
 	body, err = ioutil.ReadAll(resp.Body)
 	return
 }
+
+func (api *API) postV2(url string, payload interface{}) (body []byte, err error) {
+	return api.v2().post(url, payload)
+}
+
+// v2 returns a v2 API with the credentials and URL of the API.
+func (api *API) v2() *APIv2 {
+	return &APIv2{APIKey: api.APIKey, APISecret: api.APISecret, URL: api.URL}
+}
+
+func (api *API) baseURL() string {
+	if api.URL != "" {
+		return api.URL
+	}
+	return APIURL
+}
+
+///////////////////////////////////////
+// History paging
+///////////////////////////////////////
+
+// historyItem is a record returned by the v2 history endpoints, which are
+// sorted by timestamp, most recent first.
+type historyItem interface {
+	historyID() int
+	historyMTS() int64
+}
+
+func (o FundingOffer) historyID() int    { return o.ID }
+func (o FundingOffer) historyMTS() int64 { return o.Updated }
+
+func (c FundingCredit) historyID() int    { return c.ID }
+func (c FundingCredit) historyMTS() int64 { return c.Updated }
+
+func (t FundingTrade) historyID() int    { return t.ID }
+func (t FundingTrade) historyMTS() int64 { return t.Created }
+
+// maxHistoryLimit is the most records the history endpoints return per request.
+const maxHistoryLimit = 500
+
+// historyPager walks a history endpoint backwards in time, moving the end of
+// the requested range to the oldest record of the previous page.
+type historyPager struct {
+	page       func(start, end int64, limit int) ([]historyItem, error)
+	start, end int64
+	limit      int // Limit of the next request, raised past crowded timestamps
+	pageLimit  int // Limit asked by the caller
+	items      []historyItem
+	seen       map[int]bool // IDs returned at the end timestamp
+	done       bool
+	err        error
+}
+
+func newHistoryPager(since, until time.Time, limit int, page func(start, end int64, limit int) ([]historyItem, error)) historyPager {
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	return historyPager{
+		page:      page,
+		start:     timeToMTS(since),
+		end:       timeToMTS(until),
+		limit:     limit,
+		pageLimit: limit,
+		seen:      make(map[int]bool),
+	}
+}
+
+func (p *historyPager) next() (item historyItem, ok bool) {
+	for len(p.items) == 0 {
+		if p.done || p.err != nil {
+			return nil, false
+		}
+		p.fetch()
+	}
+
+	item, p.items = p.items[0], p.items[1:]
+	return item, true
+}
+
+func (p *historyPager) fetch() {
+	items, err := p.page(p.start, p.end, p.limit)
+	if err != nil {
+		p.err = err
+		return
+	}
+
+	if len(items) == 0 || len(items) < p.limit {
+		p.done = true
+	}
+
+	oldest := p.end
+	fresh := 0
+	for _, item := range items {
+		if item.historyMTS() < oldest {
+			oldest = item.historyMTS()
+		}
+
+		// Records at the boundary timestamp are returned again by the next page
+		if p.seen[item.historyID()] {
+			continue
+		}
+		p.seen[item.historyID()] = true
+		p.items = append(p.items, item)
+		fresh++
+	}
+
+	if fresh == 0 && !p.done {
+		// Whole page sits on the boundary timestamp, ask for more records at
+		// it, as stepping past it would skip the records not returned yet
+		limit := p.limit
+		if limit <= 0 {
+			limit = len(items) // API default
+		}
+		if limit >= maxHistoryLimit {
+			p.err = errors.New("API: More than " + strconv.Itoa(limit) + " records at timestamp " + strconv.FormatInt(oldest, 10))
+			return
+		}
+
+		p.limit = limit * 2
+		if p.limit > maxHistoryLimit {
+			p.limit = maxHistoryLimit
+		}
+		return
+	}
+
+	if oldest < p.end {
+		// Only records at the new end timestamp can be returned again
+		p.seen = make(map[int]bool)
+		for _, item := range items {
+			if item.historyMTS() == oldest {
+				p.seen[item.historyID()] = true
+			}
+		}
+		p.end = oldest
+		p.limit = p.pageLimit
+	}
+
+	if p.end < p.start {
+		p.done = true
+	}
+}
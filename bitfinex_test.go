@@ -1,9 +1,13 @@
 package bitfinex
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 )
 
 var APIKey = os.Getenv("BITFINEX_API_KEY")
@@ -35,6 +39,20 @@ func TestTicker(t *testing.T) {
 	}
 }
 
+func TestTickers(t *testing.T) {
+	// Test normal request
+	tickers, err := apiPublic.Tickers("btcusd", "fUSD")
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if tickers.Trading["tBTCUSD"].LastPrice == 0 || tickers.Funding["fUSD"].FRR == 0 {
+		t.Error("Failed")
+		return
+	}
+}
+
 func TestStats(t *testing.T) {
 	// Test normal request
 	stats, err := apiPublic.Stats("btcusd")
@@ -196,3 +214,436 @@ func TestCancelOffer(t *testing.T) {
 		return
 	}
 }
+
+func TestNewDepositAddress(t *testing.T) {
+	checkEnv(t)
+
+	address, err := apiPrivate.NewDepositAddress("bitcoin", DEPOSIT, false)
+	if err != nil || address.Address == "" {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Deposit address for " + address.Currency + ": " + address.DestinationAddress() + ", please inspect")
+	if address.PaymentID() != "" {
+		t.Log("\tPayment ID: " + address.PaymentID())
+	}
+
+	// Test bad request,
+	// which must return an error
+	_, err = apiPrivate.NewDepositAddress("random", DEPOSIT, false)
+	if err == nil {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	checkEnv(t)
+
+	transfer, err := apiPrivate.Transfer(0.0001, "BTC", DEPOSIT, EXCHANGE)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Transfer: " + transfer.Message + ", please inspect")
+
+	// Test bad request,
+	// which must return an error
+	_, err = apiPrivate.Transfer(0.0001, "random", DEPOSIT, EXCHANGE)
+	if err == nil {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestWithdrawNotAllowed(t *testing.T) {
+	api := New(APIKey, APISecret)
+
+	// Empty allow-list must refuse any destination
+	_, err := api.Withdraw(WithdrawRequest{Method: BITCOIN, Wallet: EXCHANGE, Amount: 0.01, Address: "1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV"})
+	if err != ErrWithdrawNotAllowed {
+		t.Error("Failed: withdrawal to unlisted address was not refused")
+		return
+	}
+
+	// Wire withdrawals are checked by account number
+	api.WithdrawAllowList = []string{"1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV"}
+	_, err = api.Withdraw(WithdrawRequest{Method: WIRE, Wallet: EXCHANGE, Amount: 1000, AccountNumber: "123456789"})
+	if err != ErrWithdrawNotAllowed {
+		t.Error("Failed: wire withdrawal to unlisted account was not refused")
+		return
+	}
+
+	// Payment IDs / memos are part of the destination
+	api.WithdrawAllowList = []string{"44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A:3b2f4e1c9a7d5e60"}
+	for _, paymentID := range []string{"", "3b2f4e1c9a7d5e61"} {
+		_, err = api.Withdraw(WithdrawRequest{Method: MONERO, Wallet: EXCHANGE, Amount: 1, Address: "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A", PaymentID: paymentID})
+		if err != ErrWithdrawNotAllowed {
+			t.Error("Failed: withdrawal with unlisted payment ID " + strconv.Quote(paymentID) + " was not refused")
+			return
+		}
+	}
+}
+
+func TestWithdrawMethod(t *testing.T) {
+	requests := []map[string]interface{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+
+		w.Write([]byte(`[{"status":"success","message":"Your withdrawal request has been successfully submitted.","withdrawal_id":586829}]`))
+	}))
+	defer server.Close()
+
+	api := New("", "")
+	api.URL = server.URL
+	api.WithdrawAllowList = []string{"1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV"}
+
+	// The method is matched regardless of case, wire withdrawals are checked by account number
+	_, err := api.Withdraw(WithdrawRequest{Method: "Wire", Wallet: EXCHANGE, Amount: 1000, Address: "1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV", AccountNumber: "987654321"})
+	if err != ErrWithdrawNotAllowed {
+		t.Errorf("Failed: expected ErrWithdrawNotAllowed, got %v", err)
+	}
+
+	_, err = api.Withdraw(WithdrawRequest{Method: "paypal", Wallet: EXCHANGE, Amount: 1000, Address: "1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV"})
+	if err != ErrUnknownWithdrawMethod {
+		t.Errorf("Failed: expected ErrUnknownWithdrawMethod, got %v", err)
+	}
+
+	if len(requests) != 0 {
+		t.Fatalf("Failed: unexpected requests %v", requests)
+	}
+
+	// Only the fields of the method are sent
+	withdrawal, err := api.Withdraw(WithdrawRequest{Method: "Bitcoin", Wallet: EXCHANGE, Amount: 0.01, Address: "1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV", AccountNumber: "987654321"})
+	if err != nil || withdrawal.WithdrawalID != 586829 {
+		t.Fatalf("Failed: unexpected withdrawal %+v (%v)", withdrawal, err)
+	}
+
+	if len(requests) != 1 || requests[0]["withdraw_type"] != "bitcoin" || requests[0]["address"] != "1A2wyHKJ4KWEoahDHVxwQy3kdd6g1qiSYV" || requests[0]["account_number"] != nil {
+		t.Errorf("Failed: unexpected requests %v", requests)
+	}
+}
+
+func TestKeyPermissions(t *testing.T) {
+	checkEnv(t)
+
+	permissions, err := apiPrivate.KeyPermissions()
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Detected key permissions, please inspect:")
+	for scope, p := range map[string]Permission{
+		"account":   permissions.Account,
+		"history":   permissions.History,
+		"orders":    permissions.Orders,
+		"positions": permissions.Positions,
+		"funding":   permissions.Funding,
+		"wallets":   permissions.Wallets,
+		"withdraw":  permissions.Withdraw,
+	} {
+		t.Log("\t" + scope + ": read " + strconv.FormatBool(p.Read) + ", write " + strconv.FormatBool(p.Write))
+	}
+}
+
+func TestNewFRROffer(t *testing.T) {
+	checkEnv(t)
+
+	offer, err := apiPrivate.NewFRROffer("BTC", 0.5, 2, LEND)
+	if err != nil || offer.ID == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Placed a new offer of 0.5BTC @ FRR for 2 days with ID: " + strconv.Itoa(offer.ID) + ", please inspect")
+}
+
+func TestAutoRenew(t *testing.T) {
+	checkEnv(t)
+
+	autoRenew, err := apiPrivate.SetAutoRenew("BTC", 0.5, FRR, 2)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Enabled auto-renew of " + strconv.FormatFloat(autoRenew.Threshold, 'f', -1, 64) +
+		autoRenew.Currency + " @ FRR for " + strconv.Itoa(autoRenew.Period) + " days, please inspect")
+
+	_, err = apiPrivate.DisableAutoRenew("BTC")
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestAutoRenewRequest(t *testing.T) {
+	status := "[1]"
+	requests := []map[string]interface{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/platform/status":
+			w.Write([]byte(status))
+		case "/v2/auth/w/funding/auto":
+			request := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&request)
+			requests = append(requests, request)
+
+			w.Write([]byte(`[1573912039000,"fa-req",null,null,["USD",30,0.1,500],null,"SUCCESS","Auto-renew enabled"]`))
+		}
+	}))
+	defer server.Close()
+
+	api := New("key", "secret")
+	api.URL = server.URL
+	api.CheckPlatformStatus = true
+
+	autoRenew, err := api.SetAutoRenew("usd", 500, 36.5, 30)
+	if err != nil || autoRenew.Currency != "USD" || autoRenew.Period != 30 || autoRenew.Rate != 36.5 || autoRenew.Threshold != 500 {
+		t.Errorf("Failed: unexpected auto-renew %+v (%v)", autoRenew, err)
+	}
+	if len(requests) != 1 || requests[0]["status"] != 1.0 || requests[0]["currency"] != "USD" || requests[0]["amount"] != "500" || requests[0]["rate"] != "0.1" || requests[0]["period"] != 30.0 {
+		t.Errorf("Failed: unexpected requests %v", requests)
+	}
+
+	// Only enabling is gated
+	status = "[0]"
+	if _, err = api.SetAutoRenew("usd", 500, 36.5, 30); err != ErrMaintenance {
+		t.Errorf("Failed: expected ErrMaintenance, got %v", err)
+	}
+	if _, err = api.DisableAutoRenew("usd"); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+	if len(requests) != 2 || requests[1]["status"] != 0.0 || requests[1]["amount"] != nil {
+		t.Errorf("Failed: unexpected requests %v", requests)
+	}
+}
+
+func TestFundingTrades(t *testing.T) {
+	checkEnv(t)
+
+	trades, err := apiPrivate.FundingTrades("USD", time.Time{}, time.Time{}, 25)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if len(trades) == 0 {
+		t.Log("No USD funding trades detected, please inspect")
+		return
+	}
+
+	t.Log("Detected funding trades, please inspect:")
+	for _, tr := range trades {
+		t.Log("\t" + strconv.Itoa(tr.ID) + ": " + strconv.FormatFloat(tr.Amount, 'f', -1, 64) +
+			tr.Symbol + " @ " + strconv.FormatFloat(tr.Rate*100, 'f', -1, 64) + "%/day for " + strconv.Itoa(tr.Period) + " days")
+	}
+}
+
+func TestCreditsHistory(t *testing.T) {
+	checkEnv(t)
+
+	it := apiPrivate.CreditsHistoryIter("USD", time.Now().AddDate(0, -1, 0), time.Time{}, 25)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if it.Err() != nil {
+		t.Error("Failed: " + it.Err().Error())
+		return
+	}
+
+	t.Log("Detected " + strconv.Itoa(count) + " USD credits closed during the last month, please inspect")
+}
+
+func TestOffersHistory(t *testing.T) {
+	checkEnv(t)
+
+	offers, err := apiPrivate.OffersHistory("USD", time.Time{}, time.Time{}, 25)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Detected " + strconv.Itoa(len(offers)) + " USD historical offers, please inspect")
+}
+
+func TestHistoryPager(t *testing.T) {
+	// Five trades, two of which share the timestamp of a page boundary
+	trades := FundingTrades{
+		{ID: 5, Created: 500},
+		{ID: 4, Created: 400},
+		{ID: 3, Created: 300},
+		{ID: 2, Created: 300},
+		{ID: 1, Created: 100},
+	}
+
+	requests := 0
+	pager := newHistoryPager(time.Time{}, mtsToTime(1000), 3, func(start, end int64, limit int) (items []historyItem, err error) {
+		requests++
+		for _, tr := range trades {
+			if tr.Created >= start && tr.Created <= end && len(items) < limit {
+				items = append(items, tr)
+			}
+		}
+		return
+	})
+
+	ids := []int{}
+	for {
+		item, ok := pager.next()
+		if !ok {
+			break
+		}
+		ids = append(ids, item.historyID())
+	}
+
+	if len(ids) != 5 {
+		t.Errorf("Failed: expected 5 trades, got %v", ids)
+		return
+	}
+	for i, id := range ids {
+		if id != 5-i {
+			t.Errorf("Failed: expected trades in descending order, got %v", ids)
+			return
+		}
+	}
+	if requests > 4 {
+		t.Errorf("Failed: expected at most 4 requests, made %d", requests)
+	}
+}
+
+func TestHistoryPagerBoundary(t *testing.T) {
+	// A whole page shares the boundary timestamp
+	trades := FundingTrades{{ID: 6, Created: 500}}
+	for id := 5; id > 1; id-- {
+		trades = append(trades, FundingTrade{ID: id, Created: 300})
+	}
+	trades = append(trades, FundingTrade{ID: 1, Created: 100})
+
+	page := func(start, end int64, limit int) (items []historyItem, err error) {
+		for _, tr := range trades {
+			if tr.Created >= start && tr.Created <= end && len(items) < limit {
+				items = append(items, tr)
+			}
+		}
+		return
+	}
+
+	pager := newHistoryPager(time.Time{}, mtsToTime(1000), 2, page)
+	ids := []int{}
+	for {
+		item, ok := pager.next()
+		if !ok {
+			break
+		}
+		ids = append(ids, item.historyID())
+	}
+
+	if pager.err != nil || len(ids) != 6 {
+		t.Errorf("Failed: expected 6 trades, got %v (%v)", ids, pager.err)
+	}
+
+	// The limit is raised at the boundary only, and only its IDs are kept
+	if pager.limit != 2 {
+		t.Errorf("Failed: expected the limit restored to 2, got %d", pager.limit)
+	}
+	if len(pager.seen) != 1 || !pager.seen[1] {
+		t.Errorf("Failed: expected only ID 1 seen, got %v", pager.seen)
+	}
+
+	// Records which cannot be paged past are an error, rather than skipped
+	trades = FundingTrades{{ID: 1000, Created: 500}}
+	for id := 999; id > 999-maxHistoryLimit; id-- {
+		trades = append(trades, FundingTrade{ID: id, Created: 300})
+	}
+	trades = append(trades, FundingTrade{ID: 1, Created: 100})
+
+	pager = newHistoryPager(time.Time{}, mtsToTime(1000), 2, page)
+	for {
+		if _, ok := pager.next(); !ok {
+			break
+		}
+	}
+
+	if pager.err == nil {
+		t.Error("Failed: expected an error")
+	}
+}
+
+func TestPlatformStatus(t *testing.T) {
+	operative, err := apiPublic.PlatformStatus()
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if !operative {
+		t.Log("Platform is in maintenance, please inspect")
+		return
+	}
+
+	// Gated requests must go through while the platform is operative
+	api := New("", "")
+	api.CheckPlatformStatus = true
+	err = api.CancelOffer(0)
+	if err == ErrMaintenance {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestPlatformStatusGate(t *testing.T) {
+	status := "[0]"
+	requests := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+
+		switch r.URL.Path {
+		case "/v2/platform/status":
+			w.Write([]byte(status))
+		case "/v1/offer/cancel":
+			w.Write([]byte(`{"id":1,"is_cancelled":false}`))
+		case "/v1/order/cancel/all":
+			w.Write([]byte(`{"result":"All orders cancelled"}`))
+		}
+	}))
+	defer server.Close()
+
+	api := New("", "")
+	api.URL = server.URL
+	api.CheckPlatformStatus = true
+
+	// Gated requests are not sent during maintenance
+	if err := api.CancelOffer(1); err != ErrMaintenance {
+		t.Errorf("Failed: expected ErrMaintenance, got %v", err)
+	}
+	if requests["/v2/platform/status"] != 1 || requests["/v1/offer/cancel"] != 0 {
+		t.Errorf("Failed: unexpected requests %v", requests)
+	}
+
+	// CancelAllOrders is not gated
+	if err := api.CancelAllOrders(); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+	if requests["/v2/platform/status"] != 1 || requests["/v1/order/cancel/all"] != 1 {
+		t.Errorf("Failed: unexpected requests %v", requests)
+	}
+
+	// Gated requests go through while the platform is operative
+	status = "[1]"
+	if err := api.CancelOffer(1); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+	if requests["/v2/platform/status"] != 2 || requests["/v1/offer/cancel"] != 1 {
+		t.Errorf("Failed: unexpected requests %v", requests)
+	}
+}
@@ -0,0 +1,158 @@
+package book
+
+import (
+	"sync"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// Aggregated is a Book of price levels (precisions P0 to P4). Updates with a
+// zero Count remove the level, with Amount 1 for bids and -1 for asks.
+type Aggregated struct {
+	mu   sync.RWMutex
+	bids levels
+	asks levels
+}
+
+// NewAggregated returns a new empty aggregated book
+func NewAggregated() (b *Aggregated) {
+	b = &Aggregated{
+		bids: levels{side: BID},
+		asks: levels{side: ASK},
+	}
+	return b
+}
+
+// NewFromOrderbook returns a new aggregated book with the offers of a REST
+// Orderbook, aggregating offers at the same price.
+func NewFromOrderbook(orderbook bitfinex.Orderbook) (b *Aggregated) {
+	b = NewAggregated()
+
+	for _, offer := range orderbook.Bids {
+		b.add(offer.Price, offer.Amount)
+	}
+	for _, offer := range orderbook.Asks {
+		b.add(offer.Price, -offer.Amount)
+	}
+
+	return b
+}
+
+func (b *Aggregated) add(price, amount float64) {
+	side := b.side(amount)
+
+	level, _ := side.get(price)
+	level.Price = price
+	level.Count++
+	level.Amount += amount
+	side.set(level)
+}
+
+func (b *Aggregated) side(amount float64) *levels {
+	if amount > 0 {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// Snapshot replaces the book with the levels of a snapshot.
+func (b *Aggregated) Snapshot(entries []bitfinex.BookEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bids.entries = nil
+	b.asks.entries = nil
+	for _, entry := range entries {
+		b.side(entry.Amount).set(entry)
+	}
+}
+
+// Update applies a level update.
+func (b *Aggregated) Update(entry bitfinex.BookEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if entry.Count == 0 {
+		b.side(entry.Amount).remove(entry.Price)
+		return
+	}
+
+	// Remove the level from the other side if the price crossed
+	if entry.Amount > 0 {
+		b.asks.remove(entry.Price)
+	} else {
+		b.bids.remove(entry.Price)
+	}
+	b.side(entry.Amount).set(entry)
+}
+
+// Bids returns the bid levels, best (highest) first.
+func (b *Aggregated) Bids() []bitfinex.BookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.list()
+}
+
+// Asks returns the ask levels, best (lowest) first.
+func (b *Aggregated) Asks() []bitfinex.BookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.list()
+}
+
+// BestBid returns the highest bid level.
+func (b *Aggregated) BestBid() (level bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.best()
+}
+
+// BestAsk returns the lowest ask level.
+func (b *Aggregated) BestAsk() (level bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.best()
+}
+
+// Depth returns the bid or ask level at price.
+func (b *Aggregated) Depth(price float64) (level bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	level, ok = b.bids.get(price)
+	if ok {
+		return
+	}
+	return b.asks.get(price)
+}
+
+// Volume returns the amount available on side at price or better.
+func (b *Aggregated) Volume(side Side, price float64) float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if side == BID {
+		return b.bids.volume(price)
+	}
+	return b.asks.volume(price)
+}
+
+// VWAP returns the volume weighted average price of taking size from side.
+// If the side is too shallow, the price of taking all of it is returned with
+// ErrInsufficientDepth.
+func (b *Aggregated) VWAP(side Side, size float64) (price float64, err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if side == BID {
+		return b.bids.vwap(size)
+	}
+	return b.asks.vwap(size)
+}
+
+// Checksum returns the CRC32 checksum of the top 25 bids and asks.
+func (b *Aggregated) Checksum() int32 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return checksum(b.bids.entries, b.asks.entries, false)
+}
@@ -0,0 +1,154 @@
+package book
+
+import (
+	"hash/crc32"
+	"testing"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+func newTestBook() *Aggregated {
+	b := NewAggregated()
+	b.Snapshot([]bitfinex.BookEntry{
+		{Price: 100, Count: 1, Amount: 1},
+		{Price: 99, Count: 2, Amount: 2},
+		{Price: 98, Count: 1, Amount: 3},
+		{Price: 101, Count: 1, Amount: -1},
+		{Price: 102, Count: 3, Amount: -2},
+	})
+	return b
+}
+
+func TestAggregatedQueries(t *testing.T) {
+	b := newTestBook()
+
+	bid, ok := b.BestBid()
+	if !ok || bid.Price != 100 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+
+	ask, ok := b.BestAsk()
+	if !ok || ask.Price != 101 {
+		t.Errorf("Failed: unexpected best ask %+v", ask)
+	}
+
+	if level, ok := b.Depth(99); !ok || level.Amount != 2 || level.Count != 2 {
+		t.Errorf("Failed: unexpected level %+v", level)
+	}
+	if _, ok := b.Depth(99.5); ok {
+		t.Error("Failed: expected no level at 99.5")
+	}
+
+	if volume := b.Volume(BID, 99); volume != 3 {
+		t.Errorf("Failed: expected bid volume 3, got %v", volume)
+	}
+	if volume := b.Volume(ASK, 102); volume != 3 {
+		t.Errorf("Failed: expected ask volume 3, got %v", volume)
+	}
+	if volume := b.Volume(ASK, 100); volume != 0 {
+		t.Errorf("Failed: expected ask volume 0, got %v", volume)
+	}
+
+	// Buying 2 takes 1 @ 101 and 1 @ 102
+	price, err := b.VWAP(ASK, 2)
+	if err != nil || price != 101.5 {
+		t.Errorf("Failed: expected VWAP 101.5, got %v (%v)", price, err)
+	}
+
+	// Selling 10 takes all 6 bids
+	price, err = b.VWAP(BID, 10)
+	if err != ErrInsufficientDepth || price != (100+99*2+98*3)/6.0 {
+		t.Errorf("Failed: expected insufficient depth, got %v (%v)", price, err)
+	}
+}
+
+func TestAggregatedUpdate(t *testing.T) {
+	b := newTestBook()
+
+	b.Update(bitfinex.BookEntry{Price: 100.5, Count: 1, Amount: 0.5}) // New best bid
+	b.Update(bitfinex.BookEntry{Price: 101, Count: 0, Amount: -1})    // Remove best ask
+	b.Update(bitfinex.BookEntry{Price: 99, Count: 1, Amount: 1})      // Change bid level
+	b.Update(bitfinex.BookEntry{Price: 98, Count: 0, Amount: 1})      // Remove bid level
+
+	bids := b.Bids()
+	if len(bids) != 3 || bids[0].Price != 100.5 || bids[2].Price != 99 || bids[2].Amount != 1 {
+		t.Errorf("Failed: unexpected bids %+v", bids)
+	}
+
+	asks := b.Asks()
+	if len(asks) != 1 || asks[0].Price != 102 {
+		t.Errorf("Failed: unexpected asks %+v", asks)
+	}
+
+	// Level moving to the other side
+	b.Update(bitfinex.BookEntry{Price: 102, Count: 1, Amount: 4})
+	if asks := b.Asks(); len(asks) != 0 {
+		t.Errorf("Failed: unexpected asks %+v", asks)
+	}
+	if bid, _ := b.BestBid(); bid.Price != 102 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+}
+
+func TestNewFromOrderbook(t *testing.T) {
+	b := NewFromOrderbook(bitfinex.Orderbook{
+		Bids: []bitfinex.OrderbookOffer{{Price: 100, Amount: 1}, {Price: 100, Amount: 2}, {Price: 99, Amount: 1}},
+		Asks: []bitfinex.OrderbookOffer{{Price: 101, Amount: 1.5}},
+	})
+
+	bid, _ := b.BestBid()
+	if bid.Price != 100 || bid.Amount != 3 || bid.Count != 2 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+
+	ask, _ := b.BestAsk()
+	if ask.Price != 101 || ask.Amount != -1.5 || ask.Count != 1 {
+		t.Errorf("Failed: unexpected best ask %+v", ask)
+	}
+}
+
+func TestAggregatedChecksum(t *testing.T) {
+	b := newTestBook()
+
+	expected := int32(crc32.ChecksumIEEE([]byte("100:1:101:-1:99:2:102:-2:98:3")))
+	if checksum := b.Checksum(); checksum != expected {
+		t.Errorf("Failed: expected checksum %d, got %d", expected, checksum)
+	}
+
+	// Only the top 25 levels are covered
+	for i := 0; i < 30; i++ {
+		b.Update(bitfinex.BookEntry{Price: float64(50 - i), Count: 1, Amount: 0.1})
+	}
+	b.Update(bitfinex.BookEntry{Price: 21, Count: 1, Amount: 0.2})
+	if checksum := b.Checksum(); checksum != truncated(b).Checksum() {
+		t.Error("Failed: checksum covers levels below the top 25")
+	}
+}
+
+// truncated returns a copy of b without its levels below the top 25.
+func truncated(b *Aggregated) *Aggregated {
+	c := NewAggregated()
+	c.Snapshot(append(b.Bids()[:ChecksumDepth], b.Asks()...))
+	return c
+}
+
+func TestFormatNumber(t *testing.T) {
+	numbers := map[float64]string{
+		0:          "0",
+		1:          "1",
+		-2.5:       "-2.5",
+		0.0001:     "0.0001",
+		0.000001:   "0.000001",
+		0.0000001:  "1e-7",
+		-0.0000015: "-0.0000015",
+		1.5e-8:     "1.5e-8",
+		7234.123:   "7234.123",
+		1e21:       "1e+21",
+	}
+
+	for f, expected := range numbers {
+		if s := formatNumber(f); s != expected {
+			t.Errorf("Failed: expected %v to format as %s, got %s", f, expected, s)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package book maintains Bitfinex order books locally from a snapshot and
+// incremental updates, e.g. from a WebSocket book channel.
+package book
+
+import (
+	"errors"
+	"hash/crc32"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// Side ...
+type Side int
+
+const (
+	// BID is the buy side of the book
+	BID Side = 1
+	// ASK is the sell side of the book
+	ASK Side = -1
+)
+
+// ChecksumDepth is the number of bids and asks covered by book checksums.
+const ChecksumDepth = 25
+
+var (
+	// ErrInsufficientDepth is returned by VWAP if the book is too shallow to fill the size.
+	ErrInsufficientDepth = errors.New("Book: Insufficient depth")
+	// ErrChecksum is reported when the local book diverges from the API book.
+	ErrChecksum = errors.New("Book: Checksum mismatch")
+)
+
+// Book is an order book built from a snapshot and incremental updates in the
+// format of WebSocket book channels. Books are safe for concurrent use.
+type Book interface {
+	// Snapshot replaces the book with the entries of a snapshot.
+	Snapshot(entries []bitfinex.BookEntry)
+	// Update applies an incremental update.
+	Update(entry bitfinex.BookEntry)
+
+	// Bids returns the price levels of the bids, best (highest) first.
+	Bids() []bitfinex.BookEntry
+	// Asks returns the price levels of the asks, best (lowest) first.
+	Asks() []bitfinex.BookEntry
+	// BestBid returns the highest bid level, ok is false if there are no bids.
+	BestBid() (level bitfinex.BookEntry, ok bool)
+	// BestAsk returns the lowest ask level, ok is false if there are no asks.
+	BestAsk() (level bitfinex.BookEntry, ok bool)
+	// Depth returns the level at price, ok is false if there is none.
+	Depth(price float64) (level bitfinex.BookEntry, ok bool)
+	// Volume returns the amount available on side at price or better.
+	Volume(side Side, price float64) float64
+	// VWAP returns the volume weighted average price of taking size from side,
+	// e.g. VWAP(ASK, 1) is the average price of buying 1 at market.
+	VWAP(side Side, size float64) (price float64, err error)
+
+	// Checksum returns the CRC32 checksum of the top 25 bids and asks, as sent
+	// in ws.ChecksumEvent.
+	Checksum() int32
+}
+
+///////////////////////////////////////
+// Price levels
+///////////////////////////////////////
+
+// levels is one side of a book, sorted best first.
+type levels struct {
+	side    Side
+	entries []bitfinex.BookEntry
+}
+
+// better returns true if price a is better than price b on the side.
+func (l *levels) better(a, b float64) bool {
+	if l.side == BID {
+		return a > b
+	}
+	return a < b
+}
+
+// search returns the index of the first level at price or worse.
+func (l *levels) search(price float64) int {
+	return sort.Search(len(l.entries), func(i int) bool {
+		return !l.better(l.entries[i].Price, price)
+	})
+}
+
+func (l *levels) get(price float64) (level bitfinex.BookEntry, ok bool) {
+	i := l.search(price)
+	if i < len(l.entries) && l.entries[i].Price == price {
+		return l.entries[i], true
+	}
+	return
+}
+
+// set inserts or replaces the level at entry.Price.
+func (l *levels) set(entry bitfinex.BookEntry) {
+	i := l.search(entry.Price)
+	if i < len(l.entries) && l.entries[i].Price == entry.Price {
+		l.entries[i] = entry
+		return
+	}
+
+	l.entries = append(l.entries, bitfinex.BookEntry{})
+	copy(l.entries[i+1:], l.entries[i:])
+	l.entries[i] = entry
+}
+
+func (l *levels) remove(price float64) {
+	i := l.search(price)
+	if i < len(l.entries) && l.entries[i].Price == price {
+		l.entries = append(l.entries[:i], l.entries[i+1:]...)
+	}
+}
+
+func (l *levels) best() (level bitfinex.BookEntry, ok bool) {
+	if len(l.entries) == 0 {
+		return
+	}
+	return l.entries[0], true
+}
+
+func (l *levels) list() []bitfinex.BookEntry {
+	return append([]bitfinex.BookEntry{}, l.entries...)
+}
+
+func (l *levels) volume(price float64) (volume float64) {
+	for _, level := range l.entries {
+		if l.better(price, level.Price) {
+			break
+		}
+		volume += math.Abs(level.Amount)
+	}
+	return
+}
+
+func (l *levels) vwap(size float64) (price float64, err error) {
+	if size <= 0 {
+		best, ok := l.best()
+		if !ok {
+			return 0, ErrInsufficientDepth
+		}
+		return best.Price, nil
+	}
+
+	remaining, cost := size, 0.0
+	for _, level := range l.entries {
+		amount := math.Min(math.Abs(level.Amount), remaining)
+		cost += amount * level.Price
+		remaining -= amount
+
+		if remaining <= 0 {
+			return cost / size, nil
+		}
+	}
+
+	if remaining == size {
+		return 0, ErrInsufficientDepth
+	}
+	return cost / (size - remaining), ErrInsufficientDepth
+}
+
+///////////////////////////////////////
+// Checksum
+///////////////////////////////////////
+
+// checksum returns the CRC32 checksum of "BID:AMOUNT:ASK:AMOUNT:..." for the
+// top bids and asks, where BID and ASK are the price, or the order ID for raw
+// books.
+func checksum(bids, asks []bitfinex.BookEntry, raw bool) int32 {
+	key := func(entry bitfinex.BookEntry) string {
+		if raw {
+			return strconv.Itoa(entry.ID)
+		}
+		return formatNumber(entry.Price)
+	}
+
+	parts := []string{}
+	for i := 0; i < ChecksumDepth; i++ {
+		if i < len(bids) {
+			parts = append(parts, key(bids[i]), formatNumber(bids[i].Amount))
+		}
+		if i < len(asks) {
+			parts = append(parts, key(asks[i]), formatNumber(asks[i].Amount))
+		}
+	}
+
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(parts, ":"))))
+}
+
+// formatNumber formats f the way JavaScript converts numbers to strings, which
+// the API checksums are computed from, e.g. 0.0001, 1e-7 or 1e+21.
+func formatNumber(f float64) string {
+	abs := math.Abs(f)
+	if abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	s := strconv.FormatFloat(f, 'e', -1, 64) // e.g. 1.5e-07
+	i := strings.IndexByte(s, 'e')
+	exp, _ := strconv.Atoi(s[i+1:])
+	if exp > 0 {
+		return s[:i] + "e+" + strconv.Itoa(exp)
+	}
+	return s[:i] + "e" + strconv.Itoa(exp)
+}
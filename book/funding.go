@@ -0,0 +1,260 @@
+package book
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// FundingBook is a funding book of rate levels (precisions P0 to P4), keyed by
+// rate and period. Bids are funding demands (negative amounts) and asks are
+// funding offers (positive amounts). Updates with a zero Count remove the
+// level, with Amount 1 for asks and -1 for bids.
+type FundingBook struct {
+	mu   sync.RWMutex
+	bids fundingLevels
+	asks fundingLevels
+}
+
+// NewFundingBook returns a new empty funding book
+func NewFundingBook() (b *FundingBook) {
+	b = &FundingBook{
+		bids: fundingLevels{side: BID},
+		asks: fundingLevels{side: ASK},
+	}
+	return b
+}
+
+// NewFundingFromLendbook returns a new funding book with the offers of a REST
+// Lendbook, aggregating offers at the same rate and period. Lendbook rates
+// (% per 365 days) are converted to rates per day.
+func NewFundingFromLendbook(lendbook bitfinex.Lendbook) (b *FundingBook) {
+	b = NewFundingBook()
+
+	for _, offer := range lendbook.Bids {
+		b.add(offer.Rate/365/100, offer.Period, -offer.Amount)
+	}
+	for _, offer := range lendbook.Asks {
+		b.add(offer.Rate/365/100, offer.Period, offer.Amount)
+	}
+
+	return b
+}
+
+func (b *FundingBook) add(rate float64, period int, amount float64) {
+	side := b.side(amount)
+
+	level, _ := side.get(rate, period)
+	level.Rate = rate
+	level.Period = period
+	level.Count++
+	level.Amount += amount
+	side.set(level)
+}
+
+func (b *FundingBook) side(amount float64) *fundingLevels {
+	if amount < 0 {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// Snapshot replaces the book with the levels of a snapshot.
+func (b *FundingBook) Snapshot(entries []bitfinex.FundingBookEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bids.entries = nil
+	b.asks.entries = nil
+	for _, entry := range entries {
+		b.side(entry.Amount).set(entry)
+	}
+}
+
+// Update applies a level update.
+func (b *FundingBook) Update(entry bitfinex.FundingBookEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if entry.Count == 0 {
+		b.side(entry.Amount).remove(entry.Rate, entry.Period)
+		return
+	}
+
+	// Remove the level from the other side if the rate crossed
+	if entry.Amount < 0 {
+		b.asks.remove(entry.Rate, entry.Period)
+	} else {
+		b.bids.remove(entry.Rate, entry.Period)
+	}
+	b.side(entry.Amount).set(entry)
+}
+
+// Bids returns the demand levels, best (highest rate) first.
+func (b *FundingBook) Bids() []bitfinex.FundingBookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]bitfinex.FundingBookEntry{}, b.bids.entries...)
+}
+
+// Asks returns the offer levels, best (lowest rate) first.
+func (b *FundingBook) Asks() []bitfinex.FundingBookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]bitfinex.FundingBookEntry{}, b.asks.entries...)
+}
+
+// BestBid returns the highest rate demand level, ok is false if there are no demands.
+func (b *FundingBook) BestBid() (level bitfinex.FundingBookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.best()
+}
+
+// BestAsk returns the lowest rate offer level, ok is false if there are no offers.
+func (b *FundingBook) BestAsk() (level bitfinex.FundingBookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.best()
+}
+
+// Demand returns the amount demanded at rate or above for periods of at least
+// period days.
+func (b *FundingBook) Demand(rate float64, period int) (amount float64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, level := range b.bids.entries {
+		if level.Rate < rate {
+			break
+		}
+		if level.Period >= period {
+			amount += math.Abs(level.Amount)
+		}
+	}
+	return
+}
+
+// Supply returns the amount offered at rate or below for periods of at least
+// period days.
+func (b *FundingBook) Supply(rate float64, period int) (amount float64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, level := range b.asks.entries {
+		if level.Rate > rate {
+			break
+		}
+		if level.Period >= period {
+			amount += level.Amount
+		}
+	}
+	return
+}
+
+// QueueRate returns the lowest rate at which amount is offered for periods of
+// at least period days, counting the offers at that rate or below, i.e. an
+// offer placed just below the rate would have less than amount queued ahead
+// of it. It is the inverse of Supply. ok is false if less is offered.
+func (b *FundingBook) QueueRate(amount float64, period int) (rate float64, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	offered := 0.0
+	for _, level := range b.asks.entries {
+		if level.Period < period {
+			continue
+		}
+		offered += level.Amount
+		if offered >= amount {
+			return level.Rate, true
+		}
+	}
+	return
+}
+
+// Checksum returns the CRC32 checksum of the top 25 bids and asks.
+func (b *FundingBook) Checksum() int32 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return checksum(fundingEntries(b.bids.entries), fundingEntries(b.asks.entries), false)
+}
+
+// fundingEntries converts funding levels to book entries keyed by rate, as
+// covered by checksums.
+func fundingEntries(levels []bitfinex.FundingBookEntry) (entries []bitfinex.BookEntry) {
+	for i, level := range levels {
+		if i == ChecksumDepth {
+			break
+		}
+		entries = append(entries, bitfinex.BookEntry{ID: level.ID, Price: level.Rate, Count: level.Count, Amount: level.Amount})
+	}
+	return
+}
+
+///////////////////////////////////////
+// Rate levels
+///////////////////////////////////////
+
+// fundingLevels is one side of a funding book, sorted best rate first and by
+// period within a rate.
+type fundingLevels struct {
+	side    Side
+	entries []bitfinex.FundingBookEntry
+}
+
+// before returns true if the level at rate a and period pa sorts before the
+// level at rate b and period pb.
+func (l *fundingLevels) before(a float64, pa int, b float64, pb int) bool {
+	if a != b {
+		if l.side == BID {
+			return a > b
+		}
+		return a < b
+	}
+	return pa < pb
+}
+
+// search returns the index of the first level at rate and period or after.
+func (l *fundingLevels) search(rate float64, period int) int {
+	return sort.Search(len(l.entries), func(i int) bool {
+		return !l.before(l.entries[i].Rate, l.entries[i].Period, rate, period)
+	})
+}
+
+func (l *fundingLevels) get(rate float64, period int) (level bitfinex.FundingBookEntry, ok bool) {
+	i := l.search(rate, period)
+	if i < len(l.entries) && l.entries[i].Rate == rate && l.entries[i].Period == period {
+		return l.entries[i], true
+	}
+	return
+}
+
+// set inserts or replaces the level at entry.Rate and entry.Period.
+func (l *fundingLevels) set(entry bitfinex.FundingBookEntry) {
+	i := l.search(entry.Rate, entry.Period)
+	if i < len(l.entries) && l.entries[i].Rate == entry.Rate && l.entries[i].Period == entry.Period {
+		l.entries[i] = entry
+		return
+	}
+
+	l.entries = append(l.entries, bitfinex.FundingBookEntry{})
+	copy(l.entries[i+1:], l.entries[i:])
+	l.entries[i] = entry
+}
+
+func (l *fundingLevels) remove(rate float64, period int) {
+	i := l.search(rate, period)
+	if i < len(l.entries) && l.entries[i].Rate == rate && l.entries[i].Period == period {
+		l.entries = append(l.entries[:i], l.entries[i+1:]...)
+	}
+}
+
+func (l *fundingLevels) best() (level bitfinex.FundingBookEntry, ok bool) {
+	if len(l.entries) == 0 {
+		return
+	}
+	return l.entries[0], true
+}
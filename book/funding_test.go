@@ -0,0 +1,113 @@
+package book
+
+import (
+	"hash/crc32"
+	"testing"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+func newTestFundingBook() *FundingBook {
+	b := NewFundingBook()
+	b.Snapshot([]bitfinex.FundingBookEntry{
+		{Rate: 0.0003, Period: 2, Count: 1, Amount: -100},
+		{Rate: 0.0003, Period: 30, Count: 2, Amount: -200},
+		{Rate: 0.0002, Period: 7, Count: 1, Amount: -300},
+		{Rate: 0.0004, Period: 2, Count: 3, Amount: 400},
+		{Rate: 0.0005, Period: 30, Count: 2, Amount: 500},
+		{Rate: 0.0005, Period: 2, Count: 1, Amount: 600},
+	})
+	return b
+}
+
+func TestFundingBookQueries(t *testing.T) {
+	b := newTestFundingBook()
+
+	bid, ok := b.BestBid()
+	if !ok || bid.Rate != 0.0003 || bid.Period != 2 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+
+	ask, ok := b.BestAsk()
+	if !ok || ask.Rate != 0.0004 {
+		t.Errorf("Failed: unexpected best ask %+v", ask)
+	}
+
+	if amount := b.Demand(0.0003, 0); amount != 300 {
+		t.Errorf("Failed: expected demand 300, got %v", amount)
+	}
+	if amount := b.Demand(0.0002, 7); amount != 500 {
+		t.Errorf("Failed: expected demand 500, got %v", amount)
+	}
+	if amount := b.Demand(0.0004, 0); amount != 0 {
+		t.Errorf("Failed: expected demand 0, got %v", amount)
+	}
+
+	if amount := b.Supply(0.0005, 0); amount != 1500 {
+		t.Errorf("Failed: expected supply 1500, got %v", amount)
+	}
+	if amount := b.Supply(0.0005, 30); amount != 500 {
+		t.Errorf("Failed: expected supply 500, got %v", amount)
+	}
+
+	if rate, ok := b.QueueRate(400, 0); !ok || rate != 0.0004 {
+		t.Errorf("Failed: expected queue rate 0.0004, got %v", rate)
+	}
+	if rate, ok := b.QueueRate(401, 0); !ok || rate != 0.0005 {
+		t.Errorf("Failed: expected queue rate 0.0005, got %v", rate)
+	}
+	if rate, ok := b.QueueRate(100, 30); !ok || rate != 0.0005 {
+		t.Errorf("Failed: expected queue rate 0.0005, got %v", rate)
+	}
+	if _, ok := b.QueueRate(1501, 0); ok {
+		t.Error("Failed: expected less than 1501 offered")
+	}
+	if _, ok := b.QueueRate(600, 30); ok {
+		t.Error("Failed: expected less than 600 offered for 30 days")
+	}
+}
+
+func TestFundingBookUpdate(t *testing.T) {
+	b := newTestFundingBook()
+
+	b.Update(bitfinex.FundingBookEntry{Rate: 0.0003, Period: 2, Count: 0, Amount: -1})   // Remove demand level
+	b.Update(bitfinex.FundingBookEntry{Rate: 0.0005, Period: 30, Count: 0, Amount: 1})   // Remove offer level
+	b.Update(bitfinex.FundingBookEntry{Rate: 0.00035, Period: 2, Count: 1, Amount: 50})  // New best offer
+	b.Update(bitfinex.FundingBookEntry{Rate: 0.0002, Period: 7, Count: 2, Amount: -350}) // Change demand level
+
+	bids := b.Bids()
+	if len(bids) != 2 || bids[0].Period != 30 || bids[1].Amount != -350 {
+		t.Errorf("Failed: unexpected bids %+v", bids)
+	}
+
+	asks := b.Asks()
+	if len(asks) != 3 || asks[0].Rate != 0.00035 || asks[2].Period != 2 {
+		t.Errorf("Failed: unexpected asks %+v", asks)
+	}
+}
+
+func TestNewFundingFromLendbook(t *testing.T) {
+	b := NewFundingFromLendbook(bitfinex.Lendbook{
+		Bids: []bitfinex.LendbookOffer{{Rate: 36.5, Amount: 100, Period: 30}, {Rate: 36.5, Amount: 50, Period: 30}},
+		Asks: []bitfinex.LendbookOffer{{Rate: 73, Amount: 200, Period: 2}},
+	})
+
+	bid, _ := b.BestBid()
+	if bid.Rate != 0.001 || bid.Amount != -150 || bid.Count != 2 || bid.Period != 30 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+
+	ask, _ := b.BestAsk()
+	if ask.Rate != 0.002 || ask.Amount != 200 {
+		t.Errorf("Failed: unexpected best ask %+v", ask)
+	}
+}
+
+func TestFundingBookChecksum(t *testing.T) {
+	b := newTestFundingBook()
+
+	expected := int32(crc32.ChecksumIEEE([]byte("0.0003:-100:0.0004:400:0.0003:-200:0.0005:600:0.0002:-300:0.0005:500")))
+	if checksum := b.Checksum(); checksum != expected {
+		t.Errorf("Failed: expected checksum %d, got %d", expected, checksum)
+	}
+}
@@ -0,0 +1,312 @@
+package book
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// Refill is a new order placed at the price of an order which was just
+// removed, as iceberg orders refill after their visible part executes.
+type Refill struct {
+	Price      float64 // Price level of both orders
+	OrderID    int     // ID of the new order
+	Amount     float64 // Amount of the new order
+	PreviousID int     // ID of the removed order
+	Previous   float64 // Amount of the removed order
+}
+
+// Raw is a Book of individual orders (precision R0), tracking the queue of
+// orders at every price level. Updates with a zero Price remove the order.
+// Level queries aggregate the orders at each price, with Count being the
+// number of orders.
+type Raw struct {
+	// RefillWindow is how long after an order is removed a new order at its
+	// price is reported as a Refill, 1 second by default.
+	RefillWindow time.Duration
+	// OnRefill, if set, is called with every detected Refill.
+	OnRefill func(refill Refill)
+
+	mu      sync.RWMutex
+	bids    levels
+	asks    levels
+	orders  map[int]bitfinex.BookEntry // Orders by ID
+	queues  map[float64][]int          // Order IDs by price, in queue order
+	removed map[float64]removal        // Last removed order by price, within about two RefillWindows
+	pruned  time.Time                  // Last time removed was pruned
+	now     func() time.Time
+}
+
+type removal struct {
+	order bitfinex.BookEntry
+	at    time.Time
+}
+
+// NewRaw returns a new empty raw book
+func NewRaw() (b *Raw) {
+	b = &Raw{
+		RefillWindow: time.Second,
+		bids:         levels{side: BID},
+		asks:         levels{side: ASK},
+		orders:       make(map[int]bitfinex.BookEntry),
+		queues:       make(map[float64][]int),
+		removed:      make(map[float64]removal),
+		now:          time.Now,
+	}
+	return b
+}
+
+// Snapshot replaces the book with the orders of a snapshot, queued in the
+// order received.
+func (b *Raw) Snapshot(entries []bitfinex.BookEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bids.entries = nil
+	b.asks.entries = nil
+	b.orders = make(map[int]bitfinex.BookEntry)
+	b.queues = make(map[float64][]int)
+	b.removed = make(map[float64]removal)
+
+	for _, entry := range entries {
+		b.add(entry)
+	}
+}
+
+// Update applies an order update. Orders which are moved to another price or
+// increase in amount lose their place in the queue.
+func (b *Raw) Update(entry bitfinex.BookEntry) {
+	refill, ok := b.update(entry)
+	if ok && b.OnRefill != nil {
+		b.OnRefill(refill)
+	}
+}
+
+func (b *Raw) update(entry bitfinex.BookEntry) (refill Refill, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	order, exists := b.orders[entry.ID]
+
+	if entry.Price == 0 {
+		if exists {
+			b.remove(order)
+			b.removed[order.Price] = removal{order, b.now()}
+			b.prune()
+		}
+		return
+	}
+
+	if exists && order.Price == entry.Price && math.Abs(entry.Amount) <= math.Abs(order.Amount) && sameSide(order, entry) {
+		b.orders[entry.ID] = entry
+		b.aggregate(entry.Price)
+		return
+	}
+
+	if exists {
+		b.remove(order)
+	} else if r, found := b.removed[entry.Price]; found {
+		delete(b.removed, entry.Price)
+		if b.now().Sub(r.at) <= b.RefillWindow && sameSide(r.order, entry) {
+			refill = Refill{Price: entry.Price, OrderID: entry.ID, Amount: entry.Amount, PreviousID: r.order.ID, Previous: r.order.Amount}
+			ok = true
+		}
+	}
+
+	b.add(entry)
+	return
+}
+
+// prune forgets removed orders which are too old to be refilled, once every
+// RefillWindow, so removed does not grow with every price ever traded.
+func (b *Raw) prune() {
+	now := b.now()
+	if now.Sub(b.pruned) <= b.RefillWindow {
+		return
+	}
+
+	for price, r := range b.removed {
+		if now.Sub(r.at) > b.RefillWindow {
+			delete(b.removed, price)
+		}
+	}
+	b.pruned = now
+}
+
+// add appends an order to the back of the queue at its price.
+func (b *Raw) add(order bitfinex.BookEntry) {
+	b.orders[order.ID] = order
+	b.queues[order.Price] = append(b.queues[order.Price], order.ID)
+	b.aggregate(order.Price)
+}
+
+func (b *Raw) remove(order bitfinex.BookEntry) {
+	delete(b.orders, order.ID)
+
+	queue := b.queues[order.Price]
+	for i, id := range queue {
+		if id == order.ID {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+
+	if len(queue) == 0 {
+		delete(b.queues, order.Price)
+		b.bids.remove(order.Price)
+		b.asks.remove(order.Price)
+		return
+	}
+
+	b.queues[order.Price] = queue
+	b.aggregate(order.Price)
+}
+
+// aggregate recomputes the level at price from its orders.
+func (b *Raw) aggregate(price float64) {
+	level := bitfinex.BookEntry{Price: price}
+	for _, id := range b.queues[price] {
+		level.Count++
+		level.Amount += b.orders[id].Amount
+	}
+
+	if level.Amount > 0 {
+		b.asks.remove(price)
+		b.bids.set(level)
+	} else {
+		b.bids.remove(price)
+		b.asks.set(level)
+	}
+}
+
+// Order returns the order with id, ok is false if it is not in the book.
+func (b *Raw) Order(id int) (order bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	order, ok = b.orders[id]
+	return
+}
+
+// Orders returns the orders at price in queue order.
+func (b *Raw) Orders(price float64) (orders []bitfinex.BookEntry) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, id := range b.queues[price] {
+		orders = append(orders, b.orders[id])
+	}
+	return
+}
+
+// QueuePosition estimates the place of the order with id in the queue at its
+// price, returning the number and total amount of orders ahead of it. ok is
+// false if the order is not in the book.
+func (b *Raw) QueuePosition(id int) (orders int, amount float64, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	order, ok := b.orders[id]
+	if !ok {
+		return
+	}
+
+	for _, queued := range b.queues[order.Price] {
+		if queued == id {
+			break
+		}
+		orders++
+		amount += math.Abs(b.orders[queued].Amount)
+	}
+	return
+}
+
+// Bids returns the bid levels, best (highest) first.
+func (b *Raw) Bids() []bitfinex.BookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.list()
+}
+
+// Asks returns the ask levels, best (lowest) first.
+func (b *Raw) Asks() []bitfinex.BookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.list()
+}
+
+// BestBid returns the highest bid level.
+func (b *Raw) BestBid() (level bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.best()
+}
+
+// BestAsk returns the lowest ask level.
+func (b *Raw) BestAsk() (level bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.best()
+}
+
+// Depth returns the bid or ask level at price.
+func (b *Raw) Depth(price float64) (level bitfinex.BookEntry, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	level, ok = b.bids.get(price)
+	if ok {
+		return
+	}
+	return b.asks.get(price)
+}
+
+// Volume returns the amount available on side at price or better.
+func (b *Raw) Volume(side Side, price float64) float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if side == BID {
+		return b.bids.volume(price)
+	}
+	return b.asks.volume(price)
+}
+
+// VWAP returns the volume weighted average price of taking size from side.
+// If the side is too shallow, the price of taking all of it is returned with
+// ErrInsufficientDepth.
+func (b *Raw) VWAP(side Side, size float64) (price float64, err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if side == BID {
+		return b.bids.vwap(size)
+	}
+	return b.asks.vwap(size)
+}
+
+// Checksum returns the CRC32 checksum of the top 25 bid and ask orders.
+func (b *Raw) Checksum() int32 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return checksum(b.top(&b.bids), b.top(&b.asks), true)
+}
+
+// top returns the top orders of a side, by price and queue order.
+func (b *Raw) top(side *levels) (orders []bitfinex.BookEntry) {
+	for _, level := range side.entries {
+		for _, id := range b.queues[level.Price] {
+			if len(orders) == ChecksumDepth {
+				return
+			}
+			orders = append(orders, b.orders[id])
+		}
+	}
+	return
+}
+
+func sameSide(a, b bitfinex.BookEntry) bool {
+	return (a.Amount > 0) == (b.Amount > 0)
+}
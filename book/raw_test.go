@@ -0,0 +1,134 @@
+package book
+
+import (
+	"hash/crc32"
+	"testing"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+func newTestRaw() *Raw {
+	b := NewRaw()
+	b.Snapshot([]bitfinex.BookEntry{
+		{ID: 1, Price: 100, Amount: 1},
+		{ID: 2, Price: 100, Amount: 2},
+		{ID: 3, Price: 100, Amount: 0.5},
+		{ID: 4, Price: 99, Amount: 3},
+		{ID: 5, Price: 101, Amount: -1},
+		{ID: 6, Price: 101, Amount: -2},
+	})
+	return b
+}
+
+func TestRawLevels(t *testing.T) {
+	var b Book = newTestRaw() // Raw books can be used in place of aggregated ones
+
+	bid, ok := b.BestBid()
+	if !ok || bid.Price != 100 || bid.Count != 3 || bid.Amount != 3.5 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+
+	ask, ok := b.BestAsk()
+	if !ok || ask.Price != 101 || ask.Count != 2 || ask.Amount != -3 {
+		t.Errorf("Failed: unexpected best ask %+v", ask)
+	}
+
+	if volume := b.Volume(BID, 99); volume != 6.5 {
+		t.Errorf("Failed: expected bid volume 6.5, got %v", volume)
+	}
+
+	price, err := b.VWAP(ASK, 3)
+	if err != nil || price != 101 {
+		t.Errorf("Failed: expected VWAP 101, got %v (%v)", price, err)
+	}
+}
+
+func TestRawQueuePosition(t *testing.T) {
+	b := newTestRaw()
+
+	orders, amount, ok := b.QueuePosition(3)
+	if !ok || orders != 2 || amount != 3 {
+		t.Errorf("Failed: expected 2 orders (3) ahead, got %d (%v)", orders, amount)
+	}
+
+	// Decreasing the amount keeps the place in the queue
+	b.Update(bitfinex.BookEntry{ID: 1, Price: 100, Amount: 0.5})
+	if orders, amount, _ = b.QueuePosition(3); orders != 2 || amount != 2.5 {
+		t.Errorf("Failed: expected 2 orders (2.5) ahead, got %d (%v)", orders, amount)
+	}
+
+	// Increasing the amount moves the order to the back of the queue
+	b.Update(bitfinex.BookEntry{ID: 2, Price: 100, Amount: 4})
+	if orders, amount, _ = b.QueuePosition(3); orders != 1 || amount != 0.5 {
+		t.Errorf("Failed: expected 1 order (0.5) ahead, got %d (%v)", orders, amount)
+	}
+
+	// Removed orders leave the queue
+	b.Update(bitfinex.BookEntry{ID: 1, Price: 0, Amount: 1})
+	if orders, _, _ = b.QueuePosition(3); orders != 0 {
+		t.Errorf("Failed: expected no orders ahead, got %d", orders)
+	}
+
+	queue := b.Orders(100)
+	if len(queue) != 2 || queue[0].ID != 3 || queue[1].ID != 2 {
+		t.Errorf("Failed: unexpected queue %+v", queue)
+	}
+
+	// Moved orders leave the level
+	b.Update(bitfinex.BookEntry{ID: 4, Price: 100, Amount: 3})
+	if _, ok := b.Depth(99); ok {
+		t.Error("Failed: expected no level at 99")
+	}
+	if level, _ := b.Depth(100); level.Count != 3 || level.Amount != 7.5 {
+		t.Errorf("Failed: unexpected level %+v", level)
+	}
+
+	if _, _, ok = b.QueuePosition(1); ok {
+		t.Error("Failed: expected removed order to be missing")
+	}
+}
+
+func TestRawRefill(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	b := newTestRaw()
+	b.now = func() time.Time { return now }
+
+	refills := []Refill{}
+	b.OnRefill = func(refill Refill) {
+		refills = append(refills, refill)
+	}
+
+	b.Update(bitfinex.BookEntry{ID: 5, Price: 0, Amount: -1})
+	now = now.Add(100 * time.Millisecond)
+	b.Update(bitfinex.BookEntry{ID: 7, Price: 101, Amount: -1})
+
+	// Too late
+	b.Update(bitfinex.BookEntry{ID: 4, Price: 0, Amount: 3})
+	now = now.Add(2 * time.Second)
+	b.Update(bitfinex.BookEntry{ID: 8, Price: 99, Amount: 3})
+
+	if len(refills) != 1 || refills[0].OrderID != 7 || refills[0].PreviousID != 5 || refills[0].Price != 101 {
+		t.Errorf("Failed: unexpected refills %+v", refills)
+	}
+
+	// Removals too old to be refilled are forgotten
+	for i := 0; i < 100; i++ {
+		b.Update(bitfinex.BookEntry{ID: 100 + i, Price: 200 + float64(i), Amount: -1})
+		b.Update(bitfinex.BookEntry{ID: 100 + i, Price: 0, Amount: -1})
+		now = now.Add(100 * time.Millisecond)
+	}
+	if len(b.removed) > 21 {
+		t.Errorf("Failed: expected at most 21 removed orders, got %d", len(b.removed))
+	}
+}
+
+func TestRawChecksum(t *testing.T) {
+	b := newTestRaw()
+
+	expected := int32(crc32.ChecksumIEEE([]byte("1:1:5:-1:2:2:6:-2:3:0.5:4:3")))
+	if checksum := b.Checksum(); checksum != expected {
+		t.Errorf("Failed: expected checksum %d, got %d", expected, checksum)
+	}
+}
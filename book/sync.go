@@ -0,0 +1,196 @@
+package book
+
+import (
+	"sync"
+
+	"github.com/eAndrius/bitfinex-go/ws"
+)
+
+// Sync keeps a Book or FundingBook synchronized with a WebSocket book
+// channel, verifying the book checksum after every update and resubscribing
+// when the book diverges.
+type Sync struct {
+	Book        Book         // Book synchronized by NewSync
+	FundingBook *FundingBook // Funding book synchronized by NewFundingSync
+	Request     ws.SubscribeRequest
+
+	// Errors receives ErrChecksum on every divergence and errors of failed
+	// resubscriptions. Errors which are not received in time are dropped.
+	Errors <-chan error
+
+	client *ws.Client
+	errors chan error
+	mu     sync.Mutex
+	sub    *ws.Subscription
+	gen    int // Incremented on every (re)subscription, events of older ones are ignored
+	closed bool
+}
+
+// NewSync enables checksums on the client and subscribes to the book channel
+// of request (Symbol, Precision, Frequency and Length), applying its events to b.
+func NewSync(client *ws.Client, b Book, request ws.SubscribeRequest) (s *Sync, err error) {
+	s = newSync(client, request)
+	s.Book = b
+
+	err = s.start()
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// NewFundingSync enables checksums on the client and subscribes to the
+// funding book channel of request (funding Symbol, Precision, Frequency and
+// Length), applying its events to b.
+func NewFundingSync(client *ws.Client, b *FundingBook, request ws.SubscribeRequest) (s *Sync, err error) {
+	s = newSync(client, request)
+	s.FundingBook = b
+
+	err = s.start()
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func newSync(client *ws.Client, request ws.SubscribeRequest) *Sync {
+	errors := make(chan error, 16)
+
+	request.Channel = ws.BOOK
+	request.Handler = nil
+
+	return &Sync{
+		Request: request,
+		Errors:  errors,
+		client:  client,
+		errors:  errors,
+	}
+}
+
+func (s *Sync) start() (err error) {
+	err = s.client.Configure(ws.CHECKSUM)
+	if err != nil {
+		return
+	}
+	return s.subscribe()
+}
+
+// Close unsubscribes from the book channel.
+func (s *Sync) Close() (err error) {
+	s.mu.Lock()
+	s.closed = true
+	sub := s.sub
+	s.mu.Unlock()
+
+	if sub == nil {
+		return
+	}
+	return sub.Unsubscribe()
+}
+
+func (s *Sync) subscribe() (err error) {
+	s.mu.Lock()
+	s.gen++
+	gen := s.gen
+	s.mu.Unlock()
+
+	request := s.Request
+	request.Handler = func(event interface{}) {
+		s.handle(gen, event)
+	}
+
+	sub, err := s.client.Subscribe(request)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.sub = sub
+	closed := s.closed
+	s.mu.Unlock()
+
+	if closed {
+		return sub.Unsubscribe()
+	}
+	return
+}
+
+// handle is called from the client's read loop with the events of the
+// subscription of generation gen.
+func (s *Sync) handle(gen int, event interface{}) {
+	s.mu.Lock()
+	current := gen == s.gen && !s.closed
+	s.mu.Unlock()
+
+	if !current {
+		return
+	}
+
+	// Book events of the other kind, e.g. funding book events passed to a Book,
+	// are ignored
+	switch e := event.(type) {
+	case ws.BookSnapshot:
+		if s.Book != nil {
+			s.Book.Snapshot(e.Entries)
+		}
+
+	case ws.BookUpdate:
+		if s.Book != nil {
+			s.Book.Update(e.Entry)
+		}
+
+	case ws.FundingBookSnapshot:
+		if s.FundingBook != nil {
+			s.FundingBook.Snapshot(e.Entries)
+		}
+
+	case ws.FundingBookUpdate:
+		if s.FundingBook != nil {
+			s.FundingBook.Update(e.Entry)
+		}
+
+	case ws.ChecksumEvent:
+		if s.checksum() == e.Checksum {
+			return
+		}
+
+		s.mu.Lock()
+		s.gen++ // Ignore the rest of the diverged subscription
+		sub := s.sub
+		s.mu.Unlock()
+
+		s.report(ErrChecksum)
+
+		// Subscribing waits for the read loop, which is running this handler
+		go s.resubscribe(sub)
+	}
+}
+
+func (s *Sync) checksum() int32 {
+	if s.FundingBook != nil {
+		return s.FundingBook.Checksum()
+	}
+	return s.Book.Checksum()
+}
+
+func (s *Sync) resubscribe(sub *ws.Subscription) {
+	if sub != nil {
+		if err := sub.Unsubscribe(); err != nil {
+			s.report(err)
+			return
+		}
+	}
+
+	if err := s.subscribe(); err != nil {
+		s.report(err)
+	}
+}
+
+func (s *Sync) report(err error) {
+	select {
+	case s.errors <- err:
+	default:
+	}
+}
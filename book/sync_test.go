@@ -0,0 +1,137 @@
+package book
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+	"github.com/eAndrius/bitfinex-go/ws"
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer starts a local stand-in for the WebSocket API, calling handle
+// with every message received.
+func newTestServer(t *testing.T, handle func(send func(v interface{}), msg map[string]interface{})) (*httptest.Server, string) {
+	upgrader := websocket.Upgrader{}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error("Failed: " + err.Error())
+			return
+		}
+		defer conn.Close()
+
+		var mu sync.Mutex
+		send := func(v interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			conn.WriteJSON(v)
+		}
+
+		for {
+			msg := map[string]interface{}{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			handle(send, msg)
+		}
+	}))
+
+	return s, "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func TestSync(t *testing.T) {
+	var mu sync.Mutex
+	subscriptions := 0
+
+	s, url := newTestServer(t, func(send func(v interface{}), msg map[string]interface{}) {
+		switch msg["event"] {
+		case "conf":
+			send(map[string]interface{}{"event": "conf", "status": "OK", "flags": msg["flags"]})
+
+		case "unsubscribe":
+			send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+
+		case "subscribe":
+			mu.Lock()
+			subscriptions++
+			chanID := subscriptions
+			mu.Unlock()
+
+			msg["event"] = "subscribed"
+			msg["chanId"] = chanID
+			send(msg)
+
+			send([]interface{}{chanID, []interface{}{[]interface{}{100, 1, 1}, []interface{}{101, 1, -1}}})
+			send([]interface{}{chanID, []interface{}{100, 2, 3}})
+
+			// The first subscription sends a wrong checksum
+			book := NewAggregated()
+			book.Snapshot([]bitfinex.BookEntry{{Price: 100, Count: 2, Amount: 3}, {Price: 101, Count: 1, Amount: -1}})
+			checksum := book.Checksum()
+			if chanID == 1 {
+				checksum++
+			}
+			send([]interface{}{chanID, "cs", checksum})
+		}
+	})
+	defer s.Close()
+
+	client := ws.New()
+	client.URL = url
+	client.Timeout = time.Second
+	client.Flags = 0 // The test server does not send sequence numbers
+	if err := client.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	defer client.Close()
+
+	b := NewAggregated()
+	bookSync, err := NewSync(client, b, ws.SubscribeRequest{Symbol: "tBTCUSD", Precision: bitfinex.P0})
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	select {
+	case err = <-bookSync.Errors:
+		if err != ErrChecksum {
+			t.Error("Failed: " + err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed: checksum mismatch not detected")
+	}
+
+	// Wait for the resubscription
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		done := subscriptions == 2
+		mu.Unlock()
+
+		if done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case err = <-bookSync.Errors:
+		t.Error("Failed: unexpected error " + err.Error())
+	default:
+	}
+
+	bid, _ := b.BestBid()
+	if bid.Price != 100 || bid.Amount != 3 {
+		t.Errorf("Failed: unexpected best bid %+v", bid)
+	}
+
+	err = bookSync.Close()
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+package lending
+
+import (
+	"errors"
+)
+
+// Strategy names
+const (
+	// LADDER selects the Ladder strategy
+	LADDER = "ladder"
+	// FRRDELTA selects the FRRDelta strategy
+	FRRDELTA = "frr_delta"
+)
+
+// ErrUnknownStrategy is returned by Config.Strategy for unknown strategy names.
+var ErrUnknownStrategy = errors.New("Lending: Unknown strategy")
+
+// Config selects and configures a built-in strategy, e.g. decoded from
+// JSON:
+//
+//	{
+//		"strategy": "ladder",
+//		"ladder": {"offers": 5, "nth_ask": 10, "max_rate": 30, "min_amount": 50},
+//		"periods": {"min": 2, "max": 30, "threshold": 15, "max_rate": 30}
+//	}
+//
+// Periods, if set, applies to the selected strategy.
+type Config struct {
+	Name     string   `json:"strategy"` // LADDER or FRRDELTA
+	Ladder   Ladder   `json:"ladder"`
+	FRRDelta FRRDelta `json:"frr_delta"`
+	Periods  *Periods `json:"periods"`
+}
+
+// Strategy returns the strategy selected by the config.
+func (c Config) Strategy() (strategy Strategy, err error) {
+	switch c.Name {
+	case LADDER:
+		ladder := c.Ladder
+		if c.Periods != nil {
+			ladder.Periods = *c.Periods
+		}
+		return ladder, nil
+
+	case FRRDELTA:
+		frrDelta := c.FRRDelta
+		if c.Periods != nil {
+			frrDelta.Periods = *c.Periods
+		}
+		return frrDelta, nil
+	}
+
+	return nil, ErrUnknownStrategy
+}
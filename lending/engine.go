@@ -0,0 +1,257 @@
+package lending
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// Engine runs a Strategy for one currency, on a schedule once started.
+type Engine struct {
+	API      API
+	Currency string // Currency lent, e.g. "USD"
+	Strategy Strategy
+
+	Interval  time.Duration // Delay between runs, 1 minute by default
+	BookLimit int           // Number of bids and asks of the lend book passed to the strategy, 50 by default
+
+	// RateTolerance and AmountTolerance are how far the rate (in % per 365
+	// days) and remaining amount of an active offer may be from a desired
+	// offer of the same period for the active offer to be kept. RateTolerance
+	// is 0.5 by default, so offers following a moving rate such as FRR are not
+	// replaced on every run.
+	RateTolerance   float64
+	AmountTolerance float64
+
+	// CancelOnStop, if true, makes Stop cancel all active offers of the currency.
+	CancelOnStop bool
+
+	// Errors receives the errors of scheduled runs. Errors which are not
+	// received in time are dropped.
+	Errors <-chan error
+
+	errors  chan error
+	mu      sync.Mutex
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// Result is the outcome of a run.
+type Result struct {
+	Kept      bitfinex.Offers // Active offers matching desired offers
+	Cancelled bitfinex.Offers // Active offers cancelled
+	Placed    bitfinex.Offers // Offers placed
+}
+
+// NewEngine returns a new engine running strategy on the currency.
+func NewEngine(api API, currency string, strategy Strategy) (e *Engine) {
+	errors := make(chan error, 16)
+
+	e = &Engine{
+		API:           api,
+		Currency:      strings.ToUpper(currency),
+		Strategy:      strategy,
+		Interval:      time.Minute,
+		BookLimit:     50,
+		RateTolerance: 0.5,
+		Errors:        errors,
+		errors:        errors,
+	}
+	return e
+}
+
+// Start runs the engine now and then every Interval, until Stop.
+func (e *Engine) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stop != nil {
+		return
+	}
+	e.stop = make(chan struct{})
+	e.stopped = make(chan struct{})
+
+	go e.loop(e.stop, e.stopped)
+}
+
+// Stop stops the engine, waiting for a run in progress, and cancels the
+// active offers of the currency if CancelOnStop is set.
+func (e *Engine) Stop() (err error) {
+	e.mu.Lock()
+	stop, stopped := e.stop, e.stopped
+	e.stop, e.stopped = nil, nil
+	e.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-stopped
+
+	if e.CancelOnStop {
+		err = e.API.CancelActiveOffersByCurrency(e.Currency)
+	}
+	return
+}
+
+func (e *Engine) loop(stop, stopped chan struct{}) {
+	defer close(stopped)
+
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.Run(); err != nil {
+			select {
+			case e.errors <- err:
+			default:
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run runs the strategy once: it queries the state, cancels active offers
+// the strategy does not want and places the desired offers which are not
+// active. Offers are cancelled first, to free their balance. Failed
+// cancellations and placements do not stop the run, the first error is
+// returned. If the strategy returns an error, nothing is changed.
+func (e *Engine) Run() (result Result, err error) {
+	state, err := e.State()
+	if err != nil {
+		return
+	}
+
+	offers, err := e.Strategy.Offers(state)
+	if err != nil {
+		return
+	}
+
+	desired := []Offer{}
+	for _, o := range offers {
+		if o.Amount > 0 {
+			desired = append(desired, o)
+		}
+	}
+
+	keep, cancel, place := e.diff(state.Offers, desired)
+	result.Kept = keep
+
+	for _, o := range cancel {
+		if cancelErr := e.API.CancelOffer(o.ID); cancelErr != nil {
+			if err == nil {
+				err = cancelErr
+			}
+			continue
+		}
+		result.Cancelled = append(result.Cancelled, o)
+	}
+
+	for _, o := range place {
+		offer, placeErr := e.API.NewOffer(e.Currency, o.Amount, o.Rate, o.Period, bitfinex.LEND)
+		if placeErr != nil {
+			if err == nil {
+				err = placeErr
+			}
+			continue
+		}
+		result.Placed = append(result.Placed, offer)
+	}
+
+	return
+}
+
+// State queries the lend book, deposit balance, active offers and active
+// credits of the currency, and its Flash Return Rate if the strategy is an
+// FRRStrategy using it.
+func (e *Engine) State() (state State, err error) {
+	state.Currency = e.Currency
+
+	state.Lendbook, err = e.API.Lendbook(e.Currency, e.BookLimit, e.BookLimit)
+	if err != nil {
+		return
+	}
+
+	if s, ok := e.Strategy.(FRRStrategy); ok && s.UsesFRR() {
+		symbol := "f" + e.Currency
+		tickers, err := e.API.Tickers(symbol)
+		if err != nil {
+			return state, err
+		}
+		// The ticker FRR is a daily rate
+		state.FRR = tickers.Funding[symbol].FRR * 365 * 100
+	}
+
+	balances, err := e.API.WalletBalances()
+	if err != nil {
+		return
+	}
+	state.Available = balances[bitfinex.WalletKey{Type: string(bitfinex.DEPOSIT), Currency: strings.ToLower(e.Currency)}].Available
+
+	offers, err := e.API.ActiveOffers()
+	if err != nil {
+		return
+	}
+	for _, o := range offers {
+		if isLend(o, e.Currency) {
+			state.Offers = append(state.Offers, o)
+		}
+	}
+
+	credits, err := e.API.ActiveCredits()
+	if err != nil {
+		return
+	}
+	for _, c := range credits {
+		if isCurrency(c, e.Currency) {
+			state.Credits = append(state.Credits, c)
+		}
+	}
+
+	return
+}
+
+// diff matches active offers to desired offers, returning the active offers
+// to keep and cancel, and the desired offers to place.
+func (e *Engine) diff(active bitfinex.Offers, desired []Offer) (keep, cancel bitfinex.Offers, place []Offer) {
+	matched := make([]bool, len(active))
+
+	for _, d := range desired {
+		found := false
+		for i, a := range active {
+			if !matched[i] && e.matches(a, d) {
+				matched[i], found = true, true
+				keep = append(keep, a)
+				break
+			}
+		}
+		if !found {
+			place = append(place, d)
+		}
+	}
+
+	for i, a := range active {
+		if !matched[i] {
+			cancel = append(cancel, a)
+		}
+	}
+
+	return
+}
+
+func (e *Engine) matches(active bitfinex.Offer, desired Offer) bool {
+	const epsilon = 1e-9
+
+	return active.Period == desired.Period &&
+		math.Abs(active.Rate-desired.Rate) <= e.RateTolerance+epsilon &&
+		math.Abs(active.RemainingAmount-desired.Amount) <= e.AmountTolerance+epsilon
+}
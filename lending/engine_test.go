@@ -0,0 +1,283 @@
+package lending
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+var _ API = (*bitfinex.API)(nil)
+
+// testAPI is an in-memory stand-in for the API.
+type testAPI struct {
+	mu        sync.Mutex
+	lendbook  bitfinex.Lendbook
+	frr       float64
+	available float64
+	offers    bitfinex.Offers
+	credits   bitfinex.Credits
+	nextID    int
+	cancelled []int
+	failNew   error
+	tickerErr error
+}
+
+func (api *testAPI) Lendbook(currency string, limitBids, limitAsks int) (bitfinex.Lendbook, error) {
+	return api.lendbook, nil
+}
+
+func (api *testAPI) Tickers(symbols ...string) (bitfinex.Tickers, error) {
+	if api.tickerErr != nil {
+		return bitfinex.Tickers{}, api.tickerErr
+	}
+	return bitfinex.Tickers{Funding: map[string]bitfinex.FundingTicker{"fUSD": {Symbol: "fUSD", FRR: api.frr}}}, nil
+}
+
+func (api *testAPI) WalletBalances() (bitfinex.WalletBalances, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	return bitfinex.WalletBalances{
+		{Type: "deposit", Currency: "usd"}:  {Type: "deposit", Currency: "usd", Amount: 5000, Available: api.available},
+		{Type: "exchange", Currency: "usd"}: {Type: "exchange", Currency: "usd", Amount: 100, Available: 100},
+	}, nil
+}
+
+func (api *testAPI) ActiveOffers() (bitfinex.Offers, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append(bitfinex.Offers{}, api.offers...), nil
+}
+
+func (api *testAPI) ActiveCredits() (bitfinex.Credits, error) {
+	return api.credits, nil
+}
+
+func (api *testAPI) NewOffer(currency string, amount, rate float64, period int, direction string) (offer bitfinex.Offer, err error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if api.failNew != nil {
+		return offer, api.failNew
+	}
+
+	api.nextID++
+	offer = bitfinex.Offer{ID: api.nextID, Currency: currency, Rate: rate, Period: period, Direction: direction, RemainingAmount: amount, OriginalAmount: amount}
+	api.offers = append(api.offers, offer)
+	api.available -= amount
+	return
+}
+
+func (api *testAPI) CancelOffer(id int) error {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	for i, o := range api.offers {
+		if o.ID == id {
+			api.offers = append(api.offers[:i], api.offers[i+1:]...)
+			api.cancelled = append(api.cancelled, id)
+			api.available += o.RemainingAmount
+			return nil
+		}
+	}
+	return errors.New("API: Offer could not be cancelled")
+}
+
+func (api *testAPI) CancelActiveOffersByCurrency(currency string) error {
+	api.mu.Lock()
+	offers := append(bitfinex.Offers{}, api.offers...)
+	api.mu.Unlock()
+
+	for _, o := range offers {
+		if strings.EqualFold(o.Currency, currency) {
+			api.CancelOffer(o.ID)
+		}
+	}
+	return nil
+}
+
+func newTestAPI() *testAPI {
+	return &testAPI{
+		frr:       0.0003,
+		available: 1000,
+		nextID:    100,
+		offers: bitfinex.Offers{
+			{ID: 1, Currency: "USD", Rate: 10, Period: 2, Direction: "lend", RemainingAmount: 500},
+			{ID: 2, Currency: "USD", Rate: 12, Period: 30, Direction: "lend", RemainingAmount: 1000},
+			{ID: 3, Currency: "BTC", Rate: 5, Period: 2, Direction: "lend", RemainingAmount: 1},
+			{ID: 4, Currency: "USD", Rate: 20, Period: 2, Direction: "loan", RemainingAmount: 100},
+		},
+		credits: bitfinex.Credits{
+			{ID: 10, Currency: "USD", Rate: 11, Period: 2, Amount: 2000},
+			{ID: 11, Currency: "BTC", Rate: 4, Period: 2, Amount: 2},
+		},
+	}
+}
+
+func TestState(t *testing.T) {
+	api := newTestAPI()
+	e := NewEngine(api, "usd", FRRDelta{})
+
+	state, err := e.State()
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	if state.Currency != "USD" || state.Available != 1000 || math.Abs(state.FRR-10.95) > 1e-9 {
+		t.Errorf("Failed: unexpected state %+v", state)
+	}
+	if len(state.Offers) != 2 || state.Offers[0].ID != 1 || state.Offers[1].ID != 2 {
+		t.Errorf("Failed: unexpected offers %+v", state.Offers)
+	}
+	if len(state.Credits) != 1 || state.Credits[0].ID != 10 {
+		t.Errorf("Failed: unexpected credits %+v", state.Credits)
+	}
+	if lendable := state.Lendable(); lendable != 2500 {
+		t.Errorf("Failed: expected 2500 lendable, got %v", lendable)
+	}
+	if lent := state.Lent(); lent != 2000 {
+		t.Errorf("Failed: expected 2000 lent, got %v", lent)
+	}
+
+	// The ticker is only queried for strategies using FRR
+	api.tickerErr = errors.New("API: Service unavailable")
+	if _, err = e.State(); err != api.tickerErr {
+		t.Errorf("Failed: expected ticker error, got %v", err)
+	}
+
+	e.Strategy = Ladder{}
+	state, err = e.State()
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	if state.FRR != 0 || state.Available != 1000 {
+		t.Errorf("Failed: unexpected state %+v", state)
+	}
+}
+
+func TestRun(t *testing.T) {
+	api := newTestAPI()
+
+	e := NewEngine(api, "USD", StrategyFunc(func(state State) ([]Offer, error) {
+		return []Offer{
+			{Amount: 500, Rate: 10, Period: 2},     // Kept
+			{Amount: 1500, Rate: 12.5, Period: 30}, // Replaces offer 2
+			{Amount: 0, Rate: 15, Period: 2},       // Skipped
+		}, nil
+	}))
+
+	result, err := e.Run()
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	if len(result.Kept) != 1 || result.Kept[0].ID != 1 {
+		t.Errorf("Failed: unexpected kept offers %+v", result.Kept)
+	}
+	if len(result.Cancelled) != 1 || result.Cancelled[0].ID != 2 {
+		t.Errorf("Failed: unexpected cancelled offers %+v", result.Cancelled)
+	}
+	if len(result.Placed) != 1 || result.Placed[0].RemainingAmount != 1500 || result.Placed[0].Rate != 12.5 || result.Placed[0].Direction != "lend" {
+		t.Errorf("Failed: unexpected placed offers %+v", result.Placed)
+	}
+
+	// Nothing changes once the offers match
+	result, err = e.Run()
+	if err != nil || len(result.Kept) != 2 || len(result.Cancelled) != 0 || len(result.Placed) != 0 {
+		t.Errorf("Failed: unexpected result %+v (%v)", result, err)
+	}
+
+	// Offers within tolerance are kept
+	e.Strategy = StrategyFunc(func(state State) ([]Offer, error) {
+		return []Offer{{Amount: 500, Rate: 10.4, Period: 2}, {Amount: 1500, Rate: 12.5, Period: 60}}, nil
+	})
+	api.failNew = errors.New("API: Invalid offer: incorrect amount, minimum is 50 dollar or equivalent in USD")
+
+	result, err = e.Run()
+	if err == nil || err.Error() != api.failNew.Error() {
+		t.Errorf("Failed: expected placement error, got %v", err)
+	}
+	if len(result.Kept) != 1 || len(result.Cancelled) != 1 || len(result.Placed) != 0 {
+		t.Errorf("Failed: unexpected result %+v", result)
+	}
+
+	// Nothing changes if the strategy fails
+	strategyErr := errors.New("Lending: Market unknown")
+	e.Strategy = StrategyFunc(func(state State) ([]Offer, error) {
+		return nil, strategyErr
+	})
+	offers := len(api.offers)
+
+	result, err = e.Run()
+	if err != strategyErr {
+		t.Errorf("Failed: expected strategy error, got %v", err)
+	}
+	if len(result.Kept) != 0 || len(result.Cancelled) != 0 || len(result.Placed) != 0 || len(api.offers) != offers {
+		t.Errorf("Failed: unexpected result %+v", result)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	api := newTestAPI()
+	runs := make(chan State, 10)
+
+	e := NewEngine(api, "USD", StrategyFunc(func(state State) ([]Offer, error) {
+		select {
+		case runs <- state:
+		default:
+		}
+		return []Offer{{Amount: state.Lendable(), Rate: 10, Period: 2}}, nil
+	}))
+	e.Interval = 10 * time.Millisecond
+	e.CancelOnStop = true
+
+	e.Start()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("Failed: engine not running")
+		}
+	}
+
+	if err := e.Stop(); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+
+	api.mu.Lock()
+	for _, o := range api.offers {
+		if o.Currency == "USD" {
+			t.Errorf("Failed: offer %d not cancelled", o.ID)
+		}
+	}
+	api.mu.Unlock()
+
+	select {
+	case err := <-e.Errors:
+		t.Error("Failed: " + err.Error())
+	default:
+	}
+}
+
+func TestErrors(t *testing.T) {
+	api := newTestAPI()
+	api.frr = 0
+
+	e := NewEngine(api, "USD", FRRDelta{})
+	e.Start()
+	defer e.Stop()
+
+	select {
+	case err := <-e.Errors:
+		if err != ErrFRRUnknown {
+			t.Errorf("Failed: expected ErrFRRUnknown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Failed: no error received")
+	}
+}
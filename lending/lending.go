@@ -0,0 +1,93 @@
+// Package lending runs margin funding (lending) strategies on the Bitfinex
+// API. A Strategy decides which offers should be active, the Engine cancels
+// and places offers to match.
+package lending
+
+import (
+	"strings"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// API is the part of bitfinex.API used by the engine.
+type API interface {
+	Lendbook(currency string, limitBids, limitAsks int) (bitfinex.Lendbook, error)
+	Tickers(symbols ...string) (bitfinex.Tickers, error)
+	WalletBalances() (bitfinex.WalletBalances, error)
+	ActiveOffers() (bitfinex.Offers, error)
+	ActiveCredits() (bitfinex.Credits, error)
+	NewOffer(currency string, amount, rate float64, period int, direction string) (bitfinex.Offer, error)
+	CancelOffer(id int) error
+	CancelActiveOffersByCurrency(currency string) error
+}
+
+// Offer is an offer a strategy wants to be active.
+type Offer struct {
+	Amount float64 // Amount to lend
+	Rate   float64 // Rate in % per 365 days, bitfinex.FRR for the Flash Return Rate
+	Period int     // Number of days of the loan, 2 to 120
+}
+
+// State is the state of the market and of your funds a strategy decides on.
+type State struct {
+	Currency  string            // Currency lent, e.g. "USD"
+	Lendbook  bitfinex.Lendbook // Current lend book of the currency
+	FRR       float64           // Flash Return Rate in % per 365 days, 0 if unknown or not used by the strategy
+	Available float64           // Available balance of the deposit wallet, not including active offers
+	Offers    bitfinex.Offers   // Your active lend offers of the currency
+	Credits   bitfinex.Credits  // Your active credits (lent funds) of the currency
+}
+
+// Lendable returns the amount which can be offered once the active offers
+// are cancelled, i.e. the available balance and the remaining amount of the
+// active offers.
+func (s State) Lendable() (amount float64) {
+	amount = s.Available
+	for _, o := range s.Offers {
+		amount += o.RemainingAmount
+	}
+	return
+}
+
+// Lent returns the amount of the active credits.
+func (s State) Lent() (amount float64) {
+	for _, c := range s.Credits {
+		amount += c.Amount
+	}
+	return
+}
+
+// Strategy decides which offers should be active.
+type Strategy interface {
+	// Offers returns the offers which should be active. Active offers which
+	// are not returned are cancelled, offers which are not active are placed.
+	// If an error is returned, e.g. because the market is unknown, active
+	// offers are left as they are.
+	Offers(state State) ([]Offer, error)
+}
+
+// FRRStrategy is a Strategy deciding on State.FRR. The engine only queries
+// the funding ticker for strategies whose UsesFRR returns true, so others
+// keep running when the ticker is unavailable.
+type FRRStrategy interface {
+	Strategy
+	UsesFRR() bool
+}
+
+// StrategyFunc adapts a function to a Strategy.
+type StrategyFunc func(state State) ([]Offer, error)
+
+// Offers calls f(state).
+func (f StrategyFunc) Offers(state State) ([]Offer, error) {
+	return f(state)
+}
+
+// isLend returns true for active lend offers of the currency.
+func isLend(o bitfinex.Offer, currency string) bool {
+	return strings.EqualFold(o.Currency, currency) && strings.EqualFold(o.Direction, bitfinex.LEND)
+}
+
+// isCurrency returns true for credits of the currency.
+func isCurrency(c bitfinex.Credit, currency string) bool {
+	return strings.EqualFold(c.Currency, currency)
+}
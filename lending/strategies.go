@@ -0,0 +1,146 @@
+package lending
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// MinPeriod is the shortest loan period in days
+	MinPeriod = 2
+	// MaxPeriod is the longest loan period in days
+	MaxPeriod = 120
+)
+
+var (
+	// ErrEmptyLendbook is returned by Ladder if the lend book has no asks
+	ErrEmptyLendbook = errors.New("Lending: Lend book has no asks")
+	// ErrFRRUnknown is returned by FRRDelta if the Flash Return Rate is unknown
+	ErrFRRUnknown = errors.New("Lending: Flash Return Rate unknown")
+)
+
+// Periods chooses the period of offers by rate: Min days up to the Threshold
+// rate, growing linearly to Max days at MaxRate, so high rates are locked in
+// for longer. Rates are in % per 365 days.
+type Periods struct {
+	Min       int     `json:"min"`       // Period up to Threshold, MinPeriod by default
+	Max       int     `json:"max"`       // Period from MaxRate, Min by default (fixed period)
+	Threshold float64 `json:"threshold"` // Rate up to which Min is used
+	MaxRate   float64 `json:"max_rate"`  // Rate from which Max is used
+}
+
+// Period returns the period of an offer at rate.
+func (p Periods) Period(rate float64) int {
+	min := clampPeriod(p.Min)
+	max := clampPeriod(p.Max)
+
+	switch {
+	case max <= min || rate <= p.Threshold:
+		return min
+	case rate >= p.MaxRate:
+		return max
+	}
+
+	return min + int(float64(max-min)*(rate-p.Threshold)/(p.MaxRate-p.Threshold))
+}
+
+// Ladder splits the lendable balance into offers laddered evenly between the
+// rate of the Nth ask of the lend book and MaxRate.
+type Ladder struct {
+	Count     int     `json:"offers"`     // Number of offers, 1 by default
+	NthAsk    int     `json:"nth_ask"`    // Ask of the lend book (1 for the lowest) at which the ladder starts, 1 by default
+	MaxRate   float64 `json:"max_rate"`   // Rate of the highest offer, in % per 365 days
+	MinAmount float64 `json:"min_amount"` // Minimum amount of an offer, fewer offers are placed below it
+	Periods   Periods `json:"periods"`
+}
+
+// Offers implements Strategy.
+func (l Ladder) Offers(state State) (offers []Offer, err error) {
+	asks := state.Lendbook.Asks
+	if len(asks) == 0 {
+		return nil, ErrEmptyLendbook
+	}
+
+	nth := l.NthAsk
+	if nth < 1 {
+		nth = 1
+	}
+	if nth > len(asks) {
+		nth = len(asks)
+	}
+	start := asks[nth-1].Rate
+
+	n := l.Count
+	if n < 1 {
+		n = 1
+	}
+
+	lendable := state.Lendable()
+	if l.MinAmount > 0 && lendable/float64(n) < l.MinAmount {
+		n = int(lendable / l.MinAmount)
+	}
+	if n == 0 || lendable <= 0 {
+		return
+	}
+
+	amount := floorAmount(lendable / float64(n))
+	for i := 0; i < n; i++ {
+		rate := start
+		if n > 1 && l.MaxRate > start {
+			rate = start + (l.MaxRate-start)*float64(i)/float64(n-1)
+		}
+
+		offers = append(offers, Offer{Amount: amount, Rate: rate, Period: l.Periods.Period(rate)})
+	}
+
+	return
+}
+
+// FRRDelta offers the lendable balance at the Flash Return Rate plus Delta,
+// e.g. -1 for 1% per 365 days below FRR.
+//
+// The offer is placed at a fixed rate, computed from the FRR of the funding
+// ticker on each run: between runs, and while FRR moves by less than
+// Engine.RateTolerance, its rate drifts from FRR plus Delta. Use a shorter
+// Engine.Interval or a smaller RateTolerance to follow FRR more closely.
+type FRRDelta struct {
+	Delta     float64 `json:"delta"`      // Difference to FRR, in % per 365 days
+	MinRate   float64 `json:"min_rate"`   // Minimum rate, in % per 365 days
+	MinAmount float64 `json:"min_amount"` // Minimum amount of the offer
+	Periods   Periods `json:"periods"`
+}
+
+// Offers implements Strategy.
+func (f FRRDelta) Offers(state State) (offers []Offer, err error) {
+	if state.FRR <= 0 {
+		return nil, ErrFRRUnknown
+	}
+
+	lendable := state.Lendable()
+	if lendable <= 0 || lendable < f.MinAmount {
+		return
+	}
+
+	rate := math.Max(state.FRR+f.Delta, f.MinRate)
+	return []Offer{{Amount: floorAmount(lendable), Rate: rate, Period: f.Periods.Period(rate)}}, nil
+}
+
+// UsesFRR returns true, FRRDelta is computed from State.FRR.
+func (f FRRDelta) UsesFRR() bool {
+	return true
+}
+
+func clampPeriod(period int) int {
+	if period < MinPeriod {
+		return MinPeriod
+	}
+	if period > MaxPeriod {
+		return MaxPeriod
+	}
+	return period
+}
+
+// floorAmount rounds amount down to 8 decimals, so offers do not exceed the balance.
+func floorAmount(amount float64) float64 {
+	return math.Floor(amount*1e8) / 1e8
+}
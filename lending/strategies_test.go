@@ -0,0 +1,133 @@
+package lending
+
+import (
+	"encoding/json"
+	"testing"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+func newTestState() State {
+	return State{
+		Currency: "USD",
+		FRR:      11,
+		Lendbook: bitfinex.Lendbook{
+			Bids: []bitfinex.LendbookOffer{{Rate: 9, Amount: 1000, Period: 30}},
+			Asks: []bitfinex.LendbookOffer{
+				{Rate: 10, Amount: 1000, Period: 2},
+				{Rate: 11, Amount: 1000, Period: 2, FRR: true},
+				{Rate: 12, Amount: 1000, Period: 2},
+			},
+		},
+		Available: 700,
+		Offers:    bitfinex.Offers{{ID: 1, Currency: "USD", Rate: 15, Period: 2, Direction: "lend", RemainingAmount: 300}},
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	p := Periods{Min: 2, Max: 30, Threshold: 10, MaxRate: 24}
+
+	for rate, expected := range map[float64]int{5: 2, 10: 2, 17: 16, 24: 30, 50: 30} {
+		if period := p.Period(rate); period != expected {
+			t.Errorf("Failed: expected period %d at %v, got %d", expected, rate, period)
+		}
+	}
+
+	if period := (Periods{}).Period(50); period != MinPeriod {
+		t.Errorf("Failed: expected default period %d, got %d", MinPeriod, period)
+	}
+	if period := (Periods{Min: 30}).Period(50); period != 30 {
+		t.Errorf("Failed: expected fixed period 30, got %d", period)
+	}
+	if period := (Periods{Min: 1, Max: 365}).Period(50); period != MaxPeriod {
+		t.Errorf("Failed: expected period %d, got %d", MaxPeriod, period)
+	}
+}
+
+func TestLadder(t *testing.T) {
+	l := Ladder{Count: 4, NthAsk: 2, MaxRate: 20, MinAmount: 50, Periods: Periods{Max: 30, Threshold: 15, MaxRate: 20}}
+
+	offers, err := l.Offers(newTestState())
+	if err != nil || len(offers) != 4 {
+		t.Fatalf("Failed: expected 4 offers, got %+v", offers)
+	}
+
+	rates := []float64{11, 14, 17, 20}
+	periods := []int{2, 2, 13, 30}
+	for i, o := range offers {
+		if o.Amount != 250 || o.Rate != rates[i] || o.Period != periods[i] {
+			t.Errorf("Failed: unexpected offer %+v", o)
+		}
+	}
+
+	// Fewer offers are placed below the minimum amount
+	l.MinAmount = 400
+	if offers, _ = l.Offers(newTestState()); len(offers) != 2 || offers[0].Amount != 500 || offers[1].Rate != 20 {
+		t.Errorf("Failed: unexpected offers %+v", offers)
+	}
+
+	l.MinAmount = 2000
+	if offers, _ = l.Offers(newTestState()); len(offers) != 0 {
+		t.Errorf("Failed: expected no offers, got %+v", offers)
+	}
+
+	state := newTestState()
+	state.Lendbook.Asks = nil
+	if _, err = l.Offers(state); err != ErrEmptyLendbook {
+		t.Errorf("Failed: expected ErrEmptyLendbook, got %v", err)
+	}
+}
+
+func TestFRRDelta(t *testing.T) {
+	f := FRRDelta{Delta: -0.5, MinAmount: 50}
+
+	offers, err := f.Offers(newTestState())
+	if err != nil || len(offers) != 1 || offers[0].Rate != 10.5 || offers[0].Amount != 1000 || offers[0].Period != 2 {
+		t.Errorf("Failed: unexpected offers %+v", offers)
+	}
+
+	f.MinRate = 12
+	if offers, _ = f.Offers(newTestState()); len(offers) != 1 || offers[0].Rate != 12 {
+		t.Errorf("Failed: unexpected offers %+v", offers)
+	}
+
+	state := newTestState()
+	state.FRR = 0
+	if _, err = f.Offers(state); err != ErrFRRUnknown {
+		t.Errorf("Failed: expected ErrFRRUnknown, got %v", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	config := Config{}
+	err := json.Unmarshal([]byte(`{
+		"strategy": "frr_delta",
+		"frr_delta": {"delta": 1, "min_amount": 50},
+		"periods": {"min": 2, "max": 30, "threshold": 10, "max_rate": 13}
+	}`), &config)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	strategy, err := config.Strategy()
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	offers, err := strategy.Offers(newTestState())
+	if err != nil || len(offers) != 1 || offers[0].Rate != 12 || offers[0].Period != 20 {
+		t.Errorf("Failed: unexpected offers %+v", offers)
+	}
+
+	config = Config{Name: LADDER, Ladder: Ladder{Count: 2, MaxRate: 20}}
+	if strategy, err = config.Strategy(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	if offers, _ = strategy.Offers(newTestState()); len(offers) != 2 || offers[0].Rate != 10 || offers[1].Rate != 20 {
+		t.Errorf("Failed: unexpected offers %+v", offers)
+	}
+
+	if _, err = (Config{Name: "martingale"}).Strategy(); err != ErrUnknownStrategy {
+		t.Errorf("Failed: expected ErrUnknownStrategy, got %v", err)
+	}
+}
@@ -0,0 +1,981 @@
+package bitfinex
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Precision ...
+type Precision string
+
+const (
+	// P0 aggregates the book by price with 5 significant digits
+	P0 Precision = "P0"
+	// P1 aggregates the book by price with 4 significant digits
+	P1 Precision = "P1"
+	// P2 aggregates the book by price with 3 significant digits
+	P2 Precision = "P2"
+	// P3 aggregates the book by price with 2 significant digits
+	P3 Precision = "P3"
+	// P4 aggregates the book by price with 1 significant digit
+	P4 Precision = "P4"
+	// R0 returns the raw book, one entry per order
+	R0 Precision = "R0"
+)
+
+// Timeframe ...
+type Timeframe string
+
+const (
+	// OneMinute ...
+	OneMinute Timeframe = "1m"
+	// FiveMinutes ...
+	FiveMinutes Timeframe = "5m"
+	// FifteenMinutes ...
+	FifteenMinutes Timeframe = "15m"
+	// ThirtyMinutes ...
+	ThirtyMinutes Timeframe = "30m"
+	// OneHour ...
+	OneHour Timeframe = "1h"
+	// ThreeHours ...
+	ThreeHours Timeframe = "3h"
+	// SixHours ...
+	SixHours Timeframe = "6h"
+	// TwelveHours ...
+	TwelveHours Timeframe = "12h"
+	// OneDay ...
+	OneDay Timeframe = "1D"
+	// OneWeek ...
+	OneWeek Timeframe = "7D"
+	// TwoWeeks ...
+	TwoWeeks Timeframe = "14D"
+	// OneMonth ...
+	OneMonth Timeframe = "1M"
+)
+
+const (
+	// CandlesLimit is the maximum number of candles returned by a single request
+	CandlesLimit = 10000
+	// ASC sorts results oldest first
+	ASC = 1
+	// DESC sorts results most recent first
+	DESC = -1
+)
+
+// APIv2 structure stores Bitfinex API credentials for the v2 API, found at
+// https://docs.bitfinex.com/v2/reference
+type APIv2 struct {
+	APIKey    string
+	APISecret string
+
+	// CheckPlatformStatus makes order and funding offer requests query
+	// PlatformStatus first and return ErrMaintenance during maintenance.
+	CheckPlatformStatus bool
+
+	// URL is the API URL requests are sent to, APIURL if empty.
+	URL string
+}
+
+// TradingTicker ...
+type TradingTicker struct {
+	Symbol          string  // Trading symbol, e.g. "tBTCUSD"
+	Bid             float64 `bfx:"0"` // Price of last highest bid
+	BidSize         float64 `bfx:"1"` // Sum of the 25 highest bid sizes
+	Ask             float64 `bfx:"2"` // Price of last lowest ask
+	AskSize         float64 `bfx:"3"` // Sum of the 25 lowest ask sizes
+	DailyChange     float64 `bfx:"4"` // Amount that the last price has changed since yesterday
+	DailyChangePerc float64 `bfx:"5"` // Relative price change since yesterday (*100 for percentage change)
+	LastPrice       float64 `bfx:"6"` // Price of the last trade
+	Volume          float64 `bfx:"7"` // Daily volume
+	High            float64 `bfx:"8"` // Daily high
+	Low             float64 `bfx:"9"` // Daily low
+}
+
+// FundingTicker ...
+type FundingTicker struct {
+	Symbol             string  // Funding symbol, e.g. "fUSD"
+	FRR                float64 `bfx:"0"`  // Flash Return Rate, average of all fixed rate funding over the last hour
+	Bid                float64 `bfx:"1"`  // Last highest bid rate
+	BidPeriod          int     `bfx:"2"`  // Bid period covered in days
+	BidSize            float64 `bfx:"3"`  // Sum of the 25 highest bid sizes
+	Ask                float64 `bfx:"4"`  // Last lowest ask rate
+	AskPeriod          int     `bfx:"5"`  // Ask period covered in days
+	AskSize            float64 `bfx:"6"`  // Sum of the 25 lowest ask sizes
+	DailyChange        float64 `bfx:"7"`  // Amount that the last rate has changed since yesterday
+	DailyChangePerc    float64 `bfx:"8"`  // Relative rate change since yesterday (*100 for percentage change)
+	LastPrice          float64 `bfx:"9"`  // Rate of the last trade
+	Volume             float64 `bfx:"10"` // Daily volume
+	High               float64 `bfx:"11"` // Daily high
+	Low                float64 `bfx:"12"` // Daily low
+	FRRAmountAvailable float64 `bfx:"15"` // Amount of funding available at FRR
+}
+
+// Tickers ...
+type Tickers struct {
+	Trading map[string]TradingTicker // Trading tickers keyed by symbol, e.g. "tBTCUSD"
+	Funding map[string]FundingTicker // Funding tickers keyed by symbol, e.g. "fUSD"
+}
+
+// BookEntry ...
+type BookEntry struct {
+	ID     int     // Order ID, raw (R0) books only
+	Price  float64 `bfx:"0"` // Price level
+	Count  int     `bfx:"1"` // Number of orders at the price level, aggregated books only
+	Amount float64 `bfx:"2"` // Total amount, positive for bids, negative for asks
+}
+
+// FundingBookEntry ...
+type FundingBookEntry struct {
+	ID     int     // Offer ID, raw (R0) books only
+	Rate   float64 `bfx:"0"` // Rate level
+	Period int     `bfx:"1"` // Period level in days
+	Count  int     `bfx:"2"` // Number of offers at the rate level, aggregated books only
+	Amount float64 `bfx:"3"` // Total amount, positive for asks (offers), negative for bids (demands)
+}
+
+// Trade ...
+type Trade struct {
+	ID      int     `bfx:"0"` // Trade ID
+	Created int64   `bfx:"1"` // Millisecond timestamp of execution
+	Amount  float64 `bfx:"2"` // Amount bought (positive) or sold (negative)
+	Price   float64 `bfx:"3"` // Price at which the trade was executed
+}
+
+// Trades ...
+type Trades []Trade
+
+// Candle ...
+type Candle struct {
+	MTS    int64   `bfx:"0"` // Millisecond timestamp of the candle start
+	Open   float64 `bfx:"1"` // First execution during the time frame
+	Close  float64 `bfx:"2"` // Last execution during the time frame
+	High   float64 `bfx:"3"` // Highest execution during the time frame
+	Low    float64 `bfx:"4"` // Lowest execution during the time frame
+	Volume float64 `bfx:"5"` // Quantity of symbol traded within the time frame
+}
+
+// Candles ...
+type Candles []Candle
+
+// FundingStat ...
+type FundingStat struct {
+	MTS                   int64   `bfx:"0"`  // Millisecond timestamp
+	FRR                   float64 `bfx:"3"`  // 1/365th of the Flash Return Rate, multiply by 365 for the daily rate
+	AvgPeriod             float64 `bfx:"4"`  // Average period of active funding
+	FundingAmount         float64 `bfx:"7"`  // Total funding provided
+	FundingAmountUsed     float64 `bfx:"8"`  // Total funding used in positions
+	FundingBelowThreshold float64 `bfx:"11"` // Funding offered at rates below the FRR threshold
+}
+
+// FundingStats ...
+type FundingStats []FundingStat
+
+// DerivativeStatus ...
+type DerivativeStatus struct {
+	Key                  string  `bfx:"0"`  // Derivative symbol, e.g. "tBTCF0:USTF0"
+	MTS                  int64   `bfx:"1"`  // Millisecond timestamp
+	DerivPrice           float64 `bfx:"3"`  // Last traded price of the derivative
+	SpotPrice            float64 `bfx:"4"`  // Spot price of the underlying asset
+	InsuranceFundBalance float64 `bfx:"6"`  // Balance of the insurance fund
+	NextFundingEvent     int64   `bfx:"8"`  // Millisecond timestamp of the next funding event
+	NextFundingAccrued   float64 `bfx:"9"`  // Funding accrued for the next funding event
+	NextFundingStep      int     `bfx:"10"` // Incremental accrual counter
+	CurrentFunding       float64 `bfx:"12"` // Funding applied in the current 8h period
+	MarkPrice            float64 `bfx:"15"` // Price used for liquidations and profit & loss
+	OpenInterest         float64 `bfx:"18"` // Total number of outstanding contracts
+	ClampMin             float64 `bfx:"22"` // Minimum funding rate
+	ClampMax             float64 `bfx:"23"` // Maximum funding rate
+}
+
+// MarketAverage ...
+type MarketAverage struct {
+	Price  float64 `bfx:"0"` // Average execution price, or rate for funding symbols
+	Amount float64 `bfx:"1"` // Amount which could be executed
+}
+
+// Wallet ...
+type Wallet struct {
+	Type              string  `bfx:"0"` // "exchange", "margin" or "funding"
+	Currency          string  `bfx:"1"` // Currency, e.g. "BTC"
+	Balance           float64 `bfx:"2"` // Total balance
+	UnsettledInterest float64 `bfx:"3"` // Unsettled interest
+	Available         float64 `bfx:"4"` // Balance available for orders, offers or withdrawal
+	LastChange        string  `bfx:"5"` // Description of the last ledger entry
+}
+
+// Wallets ...
+type Wallets []Wallet
+
+// Order ...
+type Order struct {
+	ID             int     `bfx:"0"`  // Order ID
+	GID            int     `bfx:"1"`  // Group ID
+	CID            int     `bfx:"2"`  // Client order ID
+	Symbol         string  `bfx:"3"`  // Trading symbol, e.g. "tBTCUSD"
+	Created        int64   `bfx:"4"`  // Millisecond timestamp of creation
+	Updated        int64   `bfx:"5"`  // Millisecond timestamp of last update
+	Amount         float64 `bfx:"6"`  // Remaining amount, positive for buy, negative for sell
+	OriginalAmount float64 `bfx:"7"`  // Amount the order was submitted for
+	Type           string  `bfx:"8"`  // E.g. "LIMIT", "MARKET", "EXCHANGE LIMIT", "STOP"
+	PreviousType   string  `bfx:"9"`  // Type before the last update
+	Flags          int     `bfx:"12"` // Order flags
+	Status         string  `bfx:"13"` // "ACTIVE", "EXECUTED @ PRICE(AMOUNT)", "PARTIALLY FILLED @ PRICE(AMOUNT)", "CANCELED", ...
+	Price          float64 `bfx:"16"` // Order price
+	PriceAvg       float64 `bfx:"17"` // Average execution price
+	PriceTrailing  float64 `bfx:"18"` // Trailing price
+	PriceAuxLimit  float64 `bfx:"19"` // Auxiliary limit price (for STOP LIMIT)
+	Notify         bool    `bfx:"23"` // Notify on execution
+	Hidden         bool    `bfx:"24"` // Hidden order
+	PlacedID       int     `bfx:"25"` // ID of the order which placed this one (OCO)
+}
+
+// Orders ...
+type Orders []Order
+
+// Position ...
+type Position struct {
+	Symbol            string  `bfx:"0"`  // Trading symbol, e.g. "tBTCUSD"
+	Status            string  `bfx:"1"`  // "ACTIVE" or "CLOSED"
+	Amount            float64 `bfx:"2"`  // Size of the position, positive for long, negative for short
+	BasePrice         float64 `bfx:"3"`  // Base price of the position
+	MarginFunding     float64 `bfx:"4"`  // Funding amount used to open the position
+	MarginFundingType int     `bfx:"5"`  // 0 for daily, 1 for term
+	PL                float64 `bfx:"6"`  // Profit & loss
+	PLPerc            float64 `bfx:"7"`  // Profit & loss in percentage
+	PriceLiq          float64 `bfx:"8"`  // Liquidation price
+	Leverage          float64 `bfx:"9"`  // Leverage used
+	ID                int     `bfx:"11"` // Position ID
+	Created           int64   `bfx:"12"` // Millisecond timestamp of creation
+	Updated           int64   `bfx:"13"` // Millisecond timestamp of last update
+	Type              int     `bfx:"15"` // 0 for margin, 1 for derivatives
+	Collateral        float64 `bfx:"17"` // Collateral of the position
+	CollateralMin     float64 `bfx:"18"` // Minimum collateral of the position
+}
+
+// Positions ...
+type Positions []Position
+
+// OrderRequest ...
+type OrderRequest struct {
+	Type          string  // E.g. "LIMIT", "MARKET", "EXCHANGE LIMIT", "STOP"
+	Symbol        string  // Trading symbol, e.g. "tBTCUSD"
+	Amount        float64 // Positive to buy, negative to sell
+	Price         float64 // Price, not used by market orders
+	PriceTrailing float64 // Trailing price, trailing stop orders only
+	PriceAuxLimit float64 // Auxiliary limit price, stop limit orders only
+	GID           int     // Optional group ID
+	CID           int     // Optional client order ID
+	Flags         int     // Order flags, e.g. 64 for hidden, 4096 for post-only
+}
+
+// FundingOfferRequest ...
+type FundingOfferRequest struct {
+	Type   string  // "LIMIT", "FRRDELTAVAR" or "FRRDELTAFIX"
+	Symbol string  // Funding symbol, e.g. "fUSD"
+	Amount float64 // Positive to lend, negative to borrow
+	Rate   float64 // Rate per day for LIMIT offers, delta from FRR for FRRDELTAVAR/FRRDELTAFIX offers
+	Period int     // Period in days, from 2 to 120
+	Flags  int     // Offer flags, e.g. 64 for hidden
+}
+
+// Notification ...
+type Notification struct {
+	MTS       int64       `bfx:"0"` // Millisecond timestamp of the notification
+	Type      string      `bfx:"1"` // Purpose of the notification, e.g. "on-req", "oc-req", "fon-req"
+	MessageID int         `bfx:"2"` // Unique ID of the message
+	Data      interface{} `bfx:"4"` // Array of data associated with the notification
+	Code      int         `bfx:"5"` // Work in progress
+	Status    string      `bfx:"6"` // "SUCCESS", "ERROR" or "FAILURE"
+	Text      string      `bfx:"7"` // Text of the notification
+}
+
+// NewV2 returns a new Bitfinex v2 API instance
+func NewV2(key, secret string) (api *APIv2) {
+	api = &APIv2{
+		APIKey:    key,
+		APISecret: secret,
+	}
+	return api
+}
+
+///////////////////////////////////////
+// Public v2 API methods
+///////////////////////////////////////
+
+// PlatformStatus returns true if the platform is operative and false if it
+// is in maintenance.
+func (api *APIv2) PlatformStatus() (operative bool, err error) {
+	status := struct {
+		Operative bool `bfx:"0"`
+	}{}
+
+	err = api.getArray("/v2/platform/status", &status)
+	return status.Operative, err
+}
+
+// Ticker returns a high level overview of the state of the market for the trading symbol.
+func (api *APIv2) Ticker(symbol string) (ticker TradingTicker, err error) {
+	symbol = tradingSymbol(symbol)
+
+	err = api.getArray("/v2/ticker/"+symbol, &ticker)
+	ticker.Symbol = symbol
+	return
+}
+
+// FundingTicker returns a high level overview of the state of the market for the funding currency.
+func (api *APIv2) FundingTicker(currency string) (ticker FundingTicker, err error) {
+	symbol := fundingSymbol(currency)
+
+	err = api.getArray("/v2/ticker/"+symbol, &ticker)
+	ticker.Symbol = symbol
+	return
+}
+
+// Tickers returns the tickers of many symbols in a single request. Symbols
+// prefixed with "f" (e.g. "fUSD") are funding symbols, any other are trading
+// symbols ("tBTCUSD" or "BTCUSD"). Tickers of all symbols are returned if
+// none are given.
+func (api *APIv2) Tickers(symbols ...string) (tickers Tickers, err error) {
+	query := []string{}
+	for _, s := range symbols {
+		if !isFundingSymbol(s) {
+			s = tradingSymbol(s)
+		}
+		query = append(query, s)
+	}
+
+	if len(query) == 0 {
+		query = append(query, "ALL")
+	}
+
+	// Each row is the ticker prefixed with its symbol
+	rows := [][]interface{}{}
+	err = api.getArray("/v2/tickers?symbols="+strings.Join(query, ","), &rows)
+	if err != nil {
+		return
+	}
+
+	tickers = Tickers{
+		Trading: make(map[string]TradingTicker),
+		Funding: make(map[string]FundingTicker),
+	}
+
+	for _, r := range rows {
+		if len(r) == 0 {
+			continue
+		}
+
+		symbol, ok := r[0].(string)
+		if !ok {
+			return tickers, errors.New("API: Unexpected tickers response")
+		}
+
+		if isFundingSymbol(symbol) {
+			ticker := FundingTicker{Symbol: symbol}
+			err = DecodeArray(r[1:], &ticker)
+			tickers.Funding[symbol] = ticker
+		} else {
+			ticker := TradingTicker{Symbol: symbol}
+			err = DecodeArray(r[1:], &ticker)
+			tickers.Trading[symbol] = ticker
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Book returns the order book of the trading symbol, aggregated by precision.
+// length (integer): Number of price points, 1, 25 or 100 (0 for the API default).
+func (api *APIv2) Book(symbol string, precision Precision, length int) (book []BookEntry, err error) {
+	url := "/v2/book/" + tradingSymbol(symbol) + "/" + string(precision) + lengthQuery(length)
+
+	if precision != R0 {
+		err = api.getArray(url, &book)
+		return
+	}
+
+	rawBook := []rawBookEntry{}
+	err = api.getArray(url, &rawBook)
+	if err != nil {
+		return
+	}
+
+	for _, e := range rawBook {
+		book = append(book, BookEntry{ID: e.ID, Price: e.Price, Amount: e.Amount})
+	}
+
+	return
+}
+
+// FundingBook returns the funding book of the currency, aggregated by precision.
+// length (integer): Number of rate points, 1, 25 or 100 (0 for the API default).
+func (api *APIv2) FundingBook(currency string, precision Precision, length int) (book []FundingBookEntry, err error) {
+	url := "/v2/book/" + fundingSymbol(currency) + "/" + string(precision) + lengthQuery(length)
+
+	if precision != R0 {
+		err = api.getArray(url, &book)
+		return
+	}
+
+	rawBook := []rawFundingBookEntry{}
+	err = api.getArray(url, &rawBook)
+	if err != nil {
+		return
+	}
+
+	for _, e := range rawBook {
+		book = append(book, FundingBookEntry{ID: e.ID, Rate: e.Rate, Period: e.Period, Amount: e.Amount})
+	}
+
+	return
+}
+
+// Trades returns up to limit trades of the trading symbol executed between
+// since and until, most recent first. Zero since and until leave the range open.
+func (api *APIv2) Trades(symbol string, since, until time.Time, limit int) (trades Trades, err error) {
+	err = api.getArray("/v2/trades/"+tradingSymbol(symbol)+"/hist"+rangeQuery(timeToMTS(since), timeToMTS(until), limit), &trades)
+	return
+}
+
+// LastCandle returns the most recent candle of the symbol, see Candles for
+// the supported symbols.
+func (api *APIv2) LastCandle(symbol string, timeframe Timeframe) (candle Candle, err error) {
+	err = api.getArray("/v2/candles/"+candleKey(symbol, timeframe)+"/last", &candle)
+	return
+}
+
+// Candles returns up to limit candles of the symbol between start and end.
+// symbol (string): Trading symbol, e.g. "tBTCUSD", or funding symbol with period, e.g. "fUSD:p30".
+// timeframe (Timeframe): Candle length, from OneMinute to OneMonth.
+// start, end (time): Range of the candles, zero values leave the range open.
+// limit (integer): Number of candles, up to CandlesLimit (0 for the API default).
+// sort (integer): ASC for oldest first, DESC for most recent first.
+func (api *APIv2) Candles(symbol string, timeframe Timeframe, start, end time.Time, limit, sort int) (candles Candles, err error) {
+	query := rangeQuery(timeToMTS(start), timeToMTS(end), limit)
+	if sort != 0 {
+		if query == "" {
+			query = "?"
+		} else {
+			query += "&"
+		}
+		query += "sort=" + strconv.Itoa(sort)
+	}
+
+	err = api.getArray("/v2/candles/"+candleKey(symbol, timeframe)+"/hist"+query, &candles)
+	return
+}
+
+// FundingStats returns up to limit funding statistics of the currency between
+// start and end, most recent first. Zero start and end leave the range open.
+func (api *APIv2) FundingStats(currency string, limit int, start, end time.Time) (stats FundingStats, err error) {
+	err = api.getArray("/v2/funding/stats/"+fundingSymbol(currency)+"/hist"+rangeQuery(timeToMTS(start), timeToMTS(end), limit), &stats)
+	return
+}
+
+// DerivativesStatus returns the status of derivative symbols, e.g.
+// "tBTCF0:USTF0", including funding rates of perpetual contracts. Status of
+// all derivatives is returned if no keys are given.
+func (api *APIv2) DerivativesStatus(keys ...string) (status []DerivativeStatus, err error) {
+	query := "ALL"
+	if len(keys) > 0 {
+		query = strings.Join(keys, ",")
+	}
+
+	err = api.getArray("/v2/status/deriv?keys="+query, &status)
+	return
+}
+
+// MarketAveragePrice returns the average execution price of a hypothetical
+// market order of amount (positive to buy, negative to sell) on the trading
+// symbol, optionally limited to prices up to priceLimit (0 for no limit).
+func (api *APIv2) MarketAveragePrice(symbol string, amount, priceLimit float64) (average MarketAverage, err error) {
+	query := "?symbol=" + tradingSymbol(symbol) + "&amount=" + strconv.FormatFloat(amount, 'f', -1, 64)
+	if priceLimit != 0 {
+		query += "&price_limit=" + strconv.FormatFloat(priceLimit, 'f', -1, 64)
+	}
+
+	err = api.postPublicArray("/v2/calc/trade/avg"+query, struct{}{}, &average)
+	return
+}
+
+// MarketAverageRate returns the average rate of a hypothetical funding of
+// amount (positive to lend, negative to borrow) of the currency for period
+// days, optionally limited to rates up to rateLimit (0 for no limit).
+func (api *APIv2) MarketAverageRate(currency string, amount float64, period int, rateLimit float64) (average MarketAverage, err error) {
+	query := "?symbol=" + fundingSymbol(currency) + "&amount=" + strconv.FormatFloat(amount, 'f', -1, 64) + "&period=" + strconv.Itoa(period)
+	if rateLimit != 0 {
+		query += "&rate_limit=" + strconv.FormatFloat(rateLimit, 'f', -1, 64)
+	}
+
+	err = api.postPublicArray("/v2/calc/trade/avg"+query, struct{}{}, &average)
+	return
+}
+
+// ExchangeRate returns the current exchange rate between two currencies, i.e.
+// how much of currency to one unit of currency from is worth.
+func (api *APIv2) ExchangeRate(from, to string) (rate float64, err error) {
+	request := struct {
+		CCY1 string `json:"ccy1"`
+		CCY2 string `json:"ccy2"`
+	}{
+		strings.ToUpper(from),
+		strings.ToUpper(to),
+	}
+
+	fx := struct {
+		Rate float64 `bfx:"0"`
+	}{}
+
+	err = api.postPublicArray("/v2/calc/fx", request, &fx)
+	return fx.Rate, err
+}
+
+///////////////////////////////////////
+// v2 API helper methods
+///////////////////////////////////////
+
+// CandlesBackfill walks all candles of the symbol between start and end,
+// oldest first, requesting CandlesLimit candles at a time and passing each
+// page to fn. Walking stops at the first error returned by fn.
+func (api *APIv2) CandlesBackfill(symbol string, timeframe Timeframe, start, end time.Time, fn func(Candles) error) (err error) {
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	for !start.After(end) {
+		var candles Candles
+		candles, err = api.Candles(symbol, timeframe, start, end, CandlesLimit, ASC)
+		if err != nil || len(candles) == 0 {
+			return
+		}
+
+		err = fn(candles)
+		if err != nil || len(candles) < CandlesLimit {
+			return
+		}
+
+		// Continue right after the last candle of the page
+		start = mtsToTime(candles[len(candles)-1].MTS + 1)
+	}
+
+	return
+}
+
+///////////////////////////////////////
+// Authenticated v2 API methods
+///////////////////////////////////////
+
+// Wallets returns your wallets and their balances.
+func (api *APIv2) Wallets() (wallets Wallets, err error) {
+	err = api.postArray("/v2/auth/r/wallets", struct{}{}, &wallets)
+	return
+}
+
+// Orders returns your active orders.
+func (api *APIv2) Orders() (orders Orders, err error) {
+	err = api.postArray("/v2/auth/r/orders", struct{}{}, &orders)
+	return
+}
+
+// Positions returns your active positions.
+func (api *APIv2) Positions() (positions Positions, err error) {
+	err = api.postArray("/v2/auth/r/positions", struct{}{}, &positions)
+	return
+}
+
+// FundingOffers returns your active funding offers of the currency.
+func (api *APIv2) FundingOffers(currency string) (offers FundingOffers, err error) {
+	err = api.postArray("/v2/auth/r/funding/offers/"+fundingSymbol(currency), struct{}{}, &offers)
+	return
+}
+
+// FundingCredits returns the funds of the currency you lent which are used in
+// a position.
+func (api *APIv2) FundingCredits(currency string) (credits FundingCredits, err error) {
+	err = api.postArray("/v2/auth/r/funding/credits/"+fundingSymbol(currency), struct{}{}, &credits)
+	return
+}
+
+// FundingLoans returns the funds of the currency you lent which are not used
+// in a position.
+func (api *APIv2) FundingLoans(currency string) (loans FundingCredits, err error) {
+	err = api.postArray("/v2/auth/r/funding/loans/"+fundingSymbol(currency), struct{}{}, &loans)
+	return
+}
+
+// SubmitOrder submits a new order.
+func (api *APIv2) SubmitOrder(order OrderRequest) (submitted Order, err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	request := struct {
+		Type          string  `json:"type"`
+		Symbol        string  `json:"symbol"`
+		Amount        float64 `json:"amount,string"`
+		Price         float64 `json:"price,string"`
+		PriceTrailing float64 `json:"price_trailing,string,omitempty"`
+		PriceAuxLimit float64 `json:"price_aux_limit,string,omitempty"`
+		GID           int     `json:"gid,omitempty"`
+		CID           int     `json:"cid,omitempty"`
+		Flags         int     `json:"flags,omitempty"`
+	}{
+		Type:          strings.ToUpper(order.Type),
+		Symbol:        tradingSymbol(order.Symbol),
+		Amount:        order.Amount,
+		Price:         order.Price,
+		PriceTrailing: order.PriceTrailing,
+		PriceAuxLimit: order.PriceAuxLimit,
+		GID:           order.GID,
+		CID:           order.CID,
+		Flags:         order.Flags,
+	}
+
+	// Submitted orders are wrapped in an array, as several can be submitted at once
+	orders := Orders{}
+	err = api.notification("/v2/auth/w/order/submit", request, &orders)
+	if err != nil {
+		return
+	}
+
+	if len(orders) == 0 {
+		return submitted, errors.New("API: Unexpected order submit response")
+	}
+
+	return orders[0], nil
+}
+
+// CancelOrder cancels an order given its id.
+func (api *APIv2) CancelOrder(id int) (cancelled Order, err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	request := struct {
+		ID int `json:"id"`
+	}{
+		id,
+	}
+
+	err = api.notification("/v2/auth/w/order/cancel", request, &cancelled)
+	return
+}
+
+// SubmitFundingOffer submits a new funding offer.
+func (api *APIv2) SubmitFundingOffer(offer FundingOfferRequest) (submitted FundingOffer, err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	request := struct {
+		Type   string  `json:"type"`
+		Symbol string  `json:"symbol"`
+		Amount float64 `json:"amount,string"`
+		Rate   float64 `json:"rate,string"`
+		Period int     `json:"period"`
+		Flags  int     `json:"flags,omitempty"`
+	}{
+		strings.ToUpper(offer.Type),
+		fundingSymbol(offer.Symbol),
+		offer.Amount,
+		offer.Rate,
+		offer.Period,
+		offer.Flags,
+	}
+
+	err = api.notification("/v2/auth/w/funding/offer/submit", request, &submitted)
+	return
+}
+
+// CancelFundingOffer cancels a funding offer given its id.
+func (api *APIv2) CancelFundingOffer(id int) (cancelled FundingOffer, err error) {
+	err = api.checkPlatformStatus()
+	if err != nil {
+		return
+	}
+
+	request := struct {
+		ID int `json:"id"`
+	}{
+		id,
+	}
+
+	err = api.notification("/v2/auth/w/funding/offer/cancel", request, &cancelled)
+	return
+}
+
+///////////////////////////////////////
+// v2 API query methods
+///////////////////////////////////////
+
+func (api *APIv2) checkPlatformStatus() (err error) {
+	if !api.CheckPlatformStatus {
+		return
+	}
+
+	operative, err := api.PlatformStatus()
+	if err != nil {
+		return
+	}
+
+	if !operative {
+		return ErrMaintenance
+	}
+
+	return
+}
+
+// getArray queries a public endpoint and decodes its array response into v.
+func (api *APIv2) getArray(url string, v interface{}) (err error) {
+	body, err := api.get(url)
+	if err != nil {
+		return
+	}
+
+	err = UnmarshalArray(body, v)
+	if err != nil { // Failed to unmarshal expected message
+		return errorV2(body, err)
+	}
+
+	return
+}
+
+// postArray queries an authenticated endpoint and decodes its array response into v.
+func (api *APIv2) postArray(url string, payload interface{}, v interface{}) (err error) {
+	body, err := api.post(url, payload)
+	if err != nil {
+		return
+	}
+
+	err = UnmarshalArray(body, v)
+	if err != nil { // Failed to unmarshal expected message
+		return errorV2(body, err)
+	}
+
+	return
+}
+
+// postPublicArray queries a public calculation endpoint and decodes its array response into v.
+func (api *APIv2) postPublicArray(url string, payload interface{}, v interface{}) (err error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	client := http.Client{
+		Timeout: time.Duration(30 * time.Second),
+	}
+
+	resp, err := client.Post(api.baseURL()+url, "application/json", bytes.NewBuffer(payloadJSON))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	err = UnmarshalArray(body, v)
+	if err != nil { // Failed to unmarshal expected message
+		return errorV2(body, err)
+	}
+
+	return
+}
+
+// notification queries an authenticated write endpoint, which responds with a
+// Notification, and decodes its data into v.
+func (api *APIv2) notification(url string, payload interface{}, v interface{}) (err error) {
+	n := Notification{}
+	err = api.postArray(url, payload, &n)
+	if err != nil {
+		return
+	}
+
+	if n.Status != "SUCCESS" {
+		return errors.New("API: " + n.Text)
+	}
+
+	return DecodeArray(n.Data, v)
+}
+
+func (api *APIv2) get(url string) (body []byte, err error) {
+	client := http.Client{
+		Timeout: time.Duration(30 * time.Second),
+	}
+
+	resp, err := client.Get(api.baseURL() + url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err = ioutil.ReadAll(resp.Body)
+	return
+}
+
+func (api *APIv2) post(url string, payload interface{}) (body []byte, err error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	// bfx-signature
+	// HMAC-SHA384("/api" + path + nonce + body, api-secret) as hexadecimal
+	// Nonce in microseconds, as for WebSocket authentication, the API rejects
+	// nonces above 2^53
+	nonce := strconv.FormatInt(time.Now().UnixNano()/1000, 10)
+	h := hmac.New(sha512.New384, []byte(api.APISecret))
+	h.Write([]byte("/api" + url + nonce + string(payloadJSON)))
+	signature := hex.EncodeToString(h.Sum(nil))
+
+	// POST
+	req, err := http.NewRequest("POST", api.baseURL()+url, bytes.NewBuffer(payloadJSON))
+	if err != nil {
+		return
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("bfx-nonce", nonce)
+	req.Header.Add("bfx-apikey", api.APIKey)
+	req.Header.Add("bfx-signature", signature)
+
+	client := http.Client{
+		Timeout: time.Duration(30 * time.Second),
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err = ioutil.ReadAll(resp.Body)
+	return
+}
+
+func (api *APIv2) baseURL() string {
+	if api.URL != "" {
+		return api.URL
+	}
+	return APIURL
+}
+
+func lengthQuery(length int) string {
+	if length == 0 {
+		return ""
+	}
+	return "?len=" + strconv.Itoa(length)
+}
+
+func rangeQuery(start, end int64, limit int) string {
+	query := []string{}
+	if start != 0 {
+		query = append(query, "start="+strconv.FormatInt(start, 10))
+	}
+	if end != 0 {
+		query = append(query, "end="+strconv.FormatInt(end, 10))
+	}
+	if limit != 0 {
+		query = append(query, "limit="+strconv.Itoa(limit))
+	}
+
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(query, "&")
+}
+
+// candleKey returns the candle key of the symbol, e.g. "trade:1m:tBTCUSD" or
+// "trade:1m:fUSD:p30".
+func candleKey(symbol string, timeframe Timeframe) string {
+	if !isFundingSymbol(symbol) {
+		symbol = tradingSymbol(symbol)
+	}
+	return "trade:" + string(timeframe) + ":" + symbol
+}
+
+func isFundingSymbol(symbol string) bool {
+	return len(symbol) > 1 && symbol[0] == 'f' && symbol[1] >= 'A' && symbol[1] <= 'Z'
+}
+
+func tradingSymbol(symbol string) string {
+	if len(symbol) > 1 && symbol[0] == 't' && symbol[1] >= 'A' && symbol[1] <= 'Z' { // Already a symbol, e.g. "tBTCUSD"
+		return symbol
+	}
+	return "t" + strings.ToUpper(symbol)
+}
+
+///////////////////////////////////////
+// v2 decoding helpers
+///////////////////////////////////////
+
+// rawBookEntry is a raw (R0) order book entry.
+type rawBookEntry struct {
+	ID     int     `bfx:"0"`
+	Price  float64 `bfx:"1"`
+	Amount float64 `bfx:"2"`
+}
+
+// rawFundingBookEntry is a raw (R0) funding book entry.
+type rawFundingBookEntry struct {
+	ID     int     `bfx:"0"`
+	Period int     `bfx:"1"`
+	Rate   float64 `bfx:"2"`
+	Amount float64 `bfx:"3"`
+}
+
+// errorV2 extracts the message of a v2 ["error", code, message] response,
+// falling back to err if body is not an error message.
+func errorV2(body []byte, err error) error {
+	errorMessage := struct {
+		Event   string `bfx:"0"`
+		Code    int    `bfx:"1"`
+		Message string `bfx:"2"`
+	}{}
+
+	if UnmarshalArray(body, &errorMessage) != nil || errorMessage.Event != "error" {
+		return err
+	}
+
+	return errors.New("API: " + errorMessage.Message)
+}
+
+func fundingSymbol(currency string) string {
+	if isFundingSymbol(currency) { // Already a symbol, e.g. "fUSD"
+		return currency
+	}
+	return "f" + strings.ToUpper(currency)
+}
+
+func timeToMTS(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func mtsToTime(mts int64) time.Time {
+	if mts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, mts*int64(time.Millisecond))
+}
@@ -0,0 +1,313 @@
+package bitfinex
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var apiPublicV2 = NewV2("", "")
+var apiPrivateV2 = NewV2(APIKey, APISecret)
+
+func TestTickerV2(t *testing.T) {
+	// Test normal request
+	ticker, err := apiPublicV2.Ticker("BTCUSD")
+	if err != nil || ticker.LastPrice == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	// Test bad request,
+	// which must return an error
+	_, err = apiPublicV2.Ticker("random")
+	if err == nil {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestFundingTickerV2(t *testing.T) {
+	ticker, err := apiPublicV2.FundingTicker("USD")
+	if err != nil || ticker.FRR == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestBookV2(t *testing.T) {
+	book, err := apiPublicV2.Book("BTCUSD", P0, 25)
+	if err != nil || len(book) == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	raw, err := apiPublicV2.Book("BTCUSD", R0, 25)
+	if err != nil || len(raw) == 0 || raw[0].ID == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestFundingBookV2(t *testing.T) {
+	book, err := apiPublicV2.FundingBook("USD", P0, 25)
+	if err != nil || len(book) == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestTradesV2(t *testing.T) {
+	trades, err := apiPublicV2.Trades("BTCUSD", time.Time{}, time.Time{}, 10)
+	if err != nil || len(trades) != 10 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestLastCandleV2(t *testing.T) {
+	candle, err := apiPublicV2.LastCandle("BTCUSD", OneMinute)
+	if err != nil || candle.MTS == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestWalletsV2(t *testing.T) {
+	checkEnv(t)
+
+	wallets, err := apiPrivateV2.Wallets()
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Detected wallets, please inspect:")
+	for _, w := range wallets {
+		t.Log("\t" + w.Type + ": " + strconv.FormatFloat(w.Balance, 'f', -1, 64) +
+			" (available: " + strconv.FormatFloat(w.Available, 'f', -1, 64) + ") " + w.Currency)
+	}
+}
+
+func TestOrdersV2(t *testing.T) {
+	checkEnv(t)
+
+	orders, err := apiPrivateV2.Orders()
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Detected " + strconv.Itoa(len(orders)) + " active orders, please inspect")
+}
+
+func TestPositionsV2(t *testing.T) {
+	checkEnv(t)
+
+	positions, err := apiPrivateV2.Positions()
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Detected " + strconv.Itoa(len(positions)) + " active positions, please inspect")
+}
+
+func TestFundingOfferV2(t *testing.T) {
+	checkEnv(t)
+
+	offer, err := apiPrivateV2.SubmitFundingOffer(FundingOfferRequest{
+		Type:   "FRRDELTAVAR",
+		Symbol: "fUSD",
+		Amount: 50,
+		Rate:   0.0001,
+		Period: 2,
+	})
+	if err != nil || offer.ID == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Placed a new offer of 50USD @ FRR+0.01%/day for 2 days with ID: " + strconv.Itoa(offer.ID) + ", please inspect")
+
+	offers, err := apiPrivateV2.FundingOffers("USD")
+	if err != nil || len(offers) == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	_, err = apiPrivateV2.CancelFundingOffer(offer.ID)
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestFundingCreditsV2(t *testing.T) {
+	checkEnv(t)
+
+	credits, err := apiPrivateV2.FundingCredits("USD")
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	loans, err := apiPrivateV2.FundingLoans("USD")
+	if err != nil {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Detected " + strconv.Itoa(len(credits)) + " USD credits and " + strconv.Itoa(len(loans)) + " USD loans, please inspect")
+}
+
+func TestTickersV2(t *testing.T) {
+	// Test normal request
+	tickers, err := apiPublicV2.Tickers("BTCUSD", "tETHUSD", "fUSD")
+	if err != nil || len(tickers.Trading) != 2 || len(tickers.Funding) != 1 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	if tickers.Trading["tBTCUSD"].LastPrice == 0 || tickers.Funding["fUSD"].FRR == 0 {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestCandlesV2(t *testing.T) {
+	// Test normal request
+	candles, err := apiPublicV2.Candles("BTCUSD", OneHour, time.Time{}, time.Time{}, 24, DESC)
+	if err != nil || len(candles) != 24 || candles[0].MTS < candles[1].MTS {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	// Funding candles
+	candles, err = apiPublicV2.Candles("fUSD:p30", OneDay, time.Time{}, time.Time{}, 7, ASC)
+	if err != nil || len(candles) == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	// Test bad request,
+	// which must return an error
+	_, err = apiPublicV2.Candles("BTCUSD", Timeframe("2m"), time.Time{}, time.Time{}, 1, DESC)
+	if err == nil {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestCandlesBackfillV2(t *testing.T) {
+	// Ten days of minute candles span two pages
+	end := time.Now().Add(-time.Hour)
+	start := end.Add(-10 * 24 * time.Hour)
+
+	count := 0
+	last := int64(0)
+	err := apiPublicV2.CandlesBackfill("BTCUSD", OneMinute, start, end, func(candles Candles) error {
+		for _, c := range candles {
+			if c.MTS <= last {
+				return errors.New("candles out of order")
+			}
+			last = c.MTS
+			count++
+		}
+		return nil
+	})
+	if err != nil || count == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Backfilled " + strconv.Itoa(count) + " BTC/USD minute candles, please inspect")
+}
+
+func TestFundingStatsV2(t *testing.T) {
+	stats, err := apiPublicV2.FundingStats("USD", 10, time.Time{}, time.Time{})
+	if err != nil || len(stats) == 0 || stats[0].FundingAmount == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Latest USD FRR: " + strconv.FormatFloat(stats[0].FRR*365*100, 'f', -1, 64) + "%/day, please inspect")
+}
+
+func TestDerivativesStatusV2(t *testing.T) {
+	status, err := apiPublicV2.DerivativesStatus("tBTCF0:USTF0")
+	if err != nil || len(status) != 1 || status[0].Key != "tBTCF0:USTF0" {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("BTC perpetual funding: " + strconv.FormatFloat(status[0].CurrentFunding, 'f', -1, 64) + ", please inspect")
+}
+
+func TestMarketAverageV2(t *testing.T) {
+	average, err := apiPublicV2.MarketAveragePrice("BTCUSD", 1.5, 0)
+	if err != nil || average.Price == 0 || average.Amount != 1.5 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	t.Log("Average price to buy 1.5BTC: " + strconv.FormatFloat(average.Price, 'f', -1, 64) + "USD, please inspect")
+
+	average, err = apiPublicV2.MarketAverageRate("USD", 10000, 2, 0)
+	if err != nil || average.Price == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+
+	// Test bad request,
+	// which must return an error
+	_, err = apiPublicV2.MarketAveragePrice("random", 1, 0)
+	if err == nil {
+		t.Error("Failed")
+		return
+	}
+}
+
+func TestExchangeRateV2(t *testing.T) {
+	rate, err := apiPublicV2.ExchangeRate("BTC", "USD")
+	if err != nil || rate == 0 {
+		t.Error("Failed: " + err.Error())
+		return
+	}
+}
+
+func TestPostV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		nonce := r.Header.Get("bfx-nonce")
+
+		// Nonces are microseconds, below 2^53
+		n, err := strconv.ParseInt(nonce, 10, 64)
+		if err != nil || n >= 1<<53 || time.Since(time.Unix(0, n*1000)) > time.Minute {
+			t.Errorf("Failed: unexpected nonce %q", nonce)
+		}
+
+		h := hmac.New(sha512.New384, []byte("secret"))
+		h.Write([]byte("/api" + r.URL.Path + nonce + string(body)))
+		if r.Header.Get("bfx-apikey") != "key" || r.Header.Get("bfx-signature") != hex.EncodeToString(h.Sum(nil)) {
+			t.Errorf("Failed: unexpected headers %v", r.Header)
+		}
+
+		w.Write([]byte(`[1573912039000,"foc-req",null,null,[41238905,"fUSD"],null,"SUCCESS","Cancelled"]`))
+	}))
+	defer server.Close()
+
+	api := NewV2("key", "secret")
+	api.URL = server.URL
+
+	offer, err := api.CancelFundingOffer(41238905)
+	if err != nil || offer.ID != 41238905 || offer.Symbol != "fUSD" {
+		t.Errorf("Failed: unexpected offer %+v (%v)", offer, err)
+	}
+}
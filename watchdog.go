@@ -0,0 +1,103 @@
+package bitfinex
+
+import (
+	"sync"
+	"time"
+)
+
+// Watchdog cancels all your active orders and funding offers when Heartbeat
+// is not called within Timeout, e.g. because the process hangs. It is the
+// REST counterpart of the WebSocket dead-man switch.
+type Watchdog struct {
+	Timeout time.Duration
+
+	// OnTrigger, if set, is called after the orders and offers are cancelled,
+	// with the error of the cancellation.
+	OnTrigger func(err error)
+
+	cancel func() error // Cancels all orders and offers
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	beat    int // Incremented on every heartbeat, so stale timers are ignored
+	stopped bool
+}
+
+// StartWatchdog returns a running watchdog which cancels all your active
+// orders and funding offers if Heartbeat is not called within timeout.
+// Heartbeat re-arms a triggered watchdog.
+func (api *API) StartWatchdog(timeout time.Duration, onTrigger func(err error)) *Watchdog {
+	w := &Watchdog{
+		Timeout:   timeout,
+		OnTrigger: onTrigger,
+		cancel:    api.cancelAll,
+	}
+	w.Heartbeat()
+	return w
+}
+
+// Heartbeat resets the timeout of the watchdog.
+func (w *Watchdog) Heartbeat() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	w.beat++
+	beat := w.beat
+	w.timer = time.AfterFunc(w.Timeout, func() { w.trigger(beat) })
+}
+
+// Stop stops the watchdog without cancelling anything.
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+func (w *Watchdog) trigger(beat int) {
+	w.mu.Lock()
+	if w.stopped || beat != w.beat { // Heartbeat received meanwhile
+		w.mu.Unlock()
+		return
+	}
+	w.mu.Unlock()
+
+	err := w.cancel()
+	if w.OnTrigger != nil {
+		w.OnTrigger(err)
+	}
+}
+
+// cancelAll cancels all active orders and funding offers, returning the first
+// error. Cancellation is best effort: it is not gated by CheckPlatformStatus
+// and goes on past failed cancellations.
+func (api *API) cancelAll() (err error) {
+	err = api.CancelAllOrders()
+
+	offers, offersErr := api.ActiveOffers()
+	if offersErr != nil {
+		if err == nil {
+			err = offersErr
+		}
+		return
+	}
+
+	for _, o := range offers {
+		if cancelErr := api.cancelOffer(o.ID); cancelErr != nil && err == nil {
+			err = cancelErr
+		}
+	}
+
+	return
+}
@@ -0,0 +1,117 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestWatchdog(timeout time.Duration, cancelErr error) (w *Watchdog, triggered chan error) {
+	triggered = make(chan error, 10)
+
+	w = &Watchdog{
+		Timeout:   timeout,
+		OnTrigger: func(err error) { triggered <- err },
+		cancel:    func() error { return cancelErr },
+	}
+	w.Heartbeat()
+	return
+}
+
+func TestWatchdog(t *testing.T) {
+	w, triggered := newTestWatchdog(100*time.Millisecond, nil)
+	defer w.Stop()
+
+	// Heartbeats keep the watchdog from triggering
+	for i := 0; i < 5; i++ {
+		time.Sleep(50 * time.Millisecond)
+		w.Heartbeat()
+	}
+
+	select {
+	case <-triggered:
+		t.Fatal("Failed: triggered despite heartbeats")
+	default:
+	}
+
+	select {
+	case err := <-triggered:
+		if err != nil {
+			t.Error("Failed: " + err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed: not triggered")
+	}
+}
+
+func TestWatchdogError(t *testing.T) {
+	w, triggered := newTestWatchdog(10*time.Millisecond, errors.New("API: Nonce is too small."))
+	defer w.Stop()
+
+	select {
+	case err := <-triggered:
+		if err == nil || err.Error() != "API: Nonce is too small." {
+			t.Errorf("Failed: expected cancellation error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed: not triggered")
+	}
+}
+
+func TestWatchdogStop(t *testing.T) {
+	w, triggered := newTestWatchdog(10*time.Millisecond, nil)
+	w.Stop()
+	w.Heartbeat() // Ignored once stopped
+
+	select {
+	case <-triggered:
+		t.Error("Failed: triggered after Stop")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCancelAll(t *testing.T) {
+	cancelled := []int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/platform/status":
+			w.Write([]byte("[0]")) // Maintenance, ignored by cancelAll
+		case "/v1/order/cancel/all":
+			w.Write([]byte(`{"result":"All orders cancelled"}`))
+		case "/v1/offers":
+			w.Write([]byte(`[{"id":1,"rate":"10"},{"id":2,"rate":"11"},{"id":3,"rate":"12"}]`))
+		case "/v1/offer/cancel":
+			request := struct {
+				OfferID int `json:"offer_id"`
+			}{}
+			json.NewDecoder(r.Body).Decode(&request)
+
+			if request.OfferID == 2 {
+				w.Write([]byte(`{"message":"Offer could not be cancelled."}`))
+				return
+			}
+			cancelled = append(cancelled, request.OfferID)
+			w.Write([]byte(`{"id":` + strconv.Itoa(request.OfferID) + `,"is_cancelled":false}`))
+		}
+	}))
+	defer server.Close()
+
+	api := New("", "")
+	api.URL = server.URL
+	api.CheckPlatformStatus = true
+
+	err := api.cancelAll()
+	if err == nil || err.Error() != "API: Offer could not be cancelled." {
+		t.Errorf("Failed: expected cancellation error, got %v", err)
+	}
+
+	// Offers after the failed one are still cancelled
+	if len(cancelled) != 2 || cancelled[0] != 1 || cancelled[1] != 3 {
+		t.Errorf("Failed: unexpected cancelled offers %v", cancelled)
+	}
+}
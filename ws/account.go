@@ -0,0 +1,240 @@
+package ws
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// ACCOUNT is the authenticated channel (channel ID 0) of account events.
+const ACCOUNT = "account"
+
+// ErrAuthenticated is returned by Authenticate if the connection is already
+// authenticated or being authenticated.
+var ErrAuthenticated = errors.New("WS: Already authenticated")
+
+// AuthRequest ...
+type AuthRequest struct {
+	APIKey    string
+	APISecret string
+
+	// Filter, if set, limits account events to the given categories, e.g.
+	// "wallet", "funding", "trading" or "notify".
+	Filter []string
+
+	// DeadMan enables the dead-man switch: the API cancels all orders of the
+	// account when the authenticated connection is lost, including when the
+	// client reconnects.
+	DeadMan bool
+
+	// Handler, if set, is called from the client's read loop with every
+	// account event instead of sending it to Subscription.Events.
+	Handler func(event interface{})
+}
+
+// Authenticate authenticates the connection, subscribing to account events:
+// WalletsSnapshot, WalletUpdate, OrdersSnapshot, OrderEvent, PositionsSnapshot,
+// PositionEvent, FundingOffersSnapshot, FundingOfferEvent,
+// FundingCreditsSnapshot, FundingCreditEvent, FundingLoansSnapshot,
+// FundingLoanEvent, AccountTradeEvent, AccountFundingTradeEvent and
+// NotificationEvent. Unsubscribing the returned subscription deauthenticates
+// the connection.
+func (c *Client) Authenticate(request AuthRequest) (sub *Subscription, err error) {
+	sub = &Subscription{
+		Request:    SubscribeRequest{Channel: ACCOUNT, Handler: request.Handler},
+		client:     c,
+		subscribed: make(chan error, 1),
+		closed:     make(chan struct{}),
+		ending:     make(chan struct{}),
+	}
+	if request.Handler == nil {
+		sub.events = make(chan interface{}, 256)
+		sub.Events = sub.events
+	}
+
+	c.mu.Lock()
+	if _, ok := c.subs[0]; ok || c.auth != nil {
+		c.mu.Unlock()
+		return nil, ErrAuthenticated
+	}
+	c.auth = sub
+	c.mu.Unlock()
+
+	err = c.send(authMessage(request))
+	if err == nil {
+		select {
+		case err = <-sub.subscribed:
+		case <-time.After(c.Timeout):
+			err = ErrTimeout
+		}
+	}
+
+	c.mu.Lock()
+	if c.auth == sub {
+		c.auth = nil
+	}
+	if err == nil {
+		c.authReq = &request
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func authMessage(request AuthRequest) map[string]interface{} {
+	// Nonce in microseconds, the API rejects nonces above 2^53
+	nonce := strconv.FormatInt(time.Now().UnixNano()/1000, 10)
+	payload := "AUTH" + nonce
+
+	// authSig
+	// HMAC-SHA384(payload, api-secret) as hexadecimal
+	h := hmac.New(sha512.New384, []byte(request.APISecret))
+	h.Write([]byte(payload))
+	signature := hex.EncodeToString(h.Sum(nil))
+
+	msg := map[string]interface{}{
+		"event":       "auth",
+		"apiKey":      request.APIKey,
+		"authSig":     signature,
+		"authPayload": payload,
+		"authNonce":   nonce,
+	}
+
+	if len(request.Filter) > 0 {
+		msg["filter"] = request.Filter
+	}
+
+	if request.DeadMan {
+		msg["dms"] = 4
+	}
+
+	return msg
+}
+
+// handleAuth handles "auth" events, the response to Authenticate or to the
+// authentication repeated after reconnecting.
+func (c *Client) handleAuth(e event) {
+	c.mu.Lock()
+	sub := c.auth
+	c.auth = nil
+	resync := false
+	if sub != nil {
+		resync, sub.resync = sub.resync, false
+		if e.Status == "OK" {
+			sub.ChanID = e.ChanID
+			c.subs[e.ChanID] = sub
+		} else if resync {
+			c.authReq = nil
+		}
+	}
+	c.mu.Unlock()
+
+	if sub == nil {
+		return
+	}
+
+	switch {
+	case resync && e.Status == "OK" && sub.cancelled:
+		c.send(map[string]interface{}{"event": "unauth"})
+	case resync && e.Status == "OK":
+		c.deliver(sub, ResyncEvent{})
+	case resync:
+		sub.end()
+		c.emit(ErrorEvent{Code: e.Code, Msg: e.Msg})
+	case e.Status == "OK":
+		sub.subscribed <- nil
+	default:
+		sub.subscribed <- errors.New("API: " + e.Msg)
+	}
+}
+
+// decodeAccount decodes [0, "TYPE", DATA] account messages.
+func decodeAccount(kind string, raw interface{}) (e interface{}, err error) {
+	switch kind {
+	case "n":
+		n := NotificationEvent{}
+		err = bitfinex.DecodeArray(raw, &n.Notification)
+		return n, err
+
+	case "ws":
+		wallets := WalletsSnapshot{}
+		err = bitfinex.DecodeArray(raw, &wallets.Wallets)
+		return wallets, err
+
+	case "wu":
+		wallet := WalletUpdate{}
+		err = bitfinex.DecodeArray(raw, &wallet.Wallet)
+		return wallet, err
+
+	case "os":
+		orders := OrdersSnapshot{}
+		err = bitfinex.DecodeArray(raw, &orders.Orders)
+		return orders, err
+
+	case "on", "ou", "oc":
+		order := OrderEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &order.Order)
+		return order, err
+
+	case "ps":
+		positions := PositionsSnapshot{}
+		err = bitfinex.DecodeArray(raw, &positions.Positions)
+		return positions, err
+
+	case "pn", "pu", "pc":
+		position := PositionEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &position.Position)
+		return position, err
+
+	case "fos":
+		offers := FundingOffersSnapshot{}
+		err = bitfinex.DecodeArray(raw, &offers.Offers)
+		return offers, err
+
+	case "fon", "fou", "foc":
+		offer := FundingOfferEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &offer.Offer)
+		return offer, err
+
+	case "fcs":
+		credits := FundingCreditsSnapshot{}
+		err = bitfinex.DecodeArray(raw, &credits.Credits)
+		return credits, err
+
+	case "fcn", "fcu", "fcc":
+		credit := FundingCreditEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &credit.Credit)
+		return credit, err
+
+	case "fls":
+		loans := FundingLoansSnapshot{}
+		err = bitfinex.DecodeArray(raw, &loans.Loans)
+		return loans, err
+
+	case "fln", "flu", "flc":
+		loan := FundingLoanEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &loan.Loan)
+		return loan, err
+
+	case "te", "tu":
+		trade := AccountTradeEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &trade.Trade)
+		return trade, err
+
+	case "fte", "ftu":
+		trade := AccountFundingTradeEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &trade.Trade)
+		return trade, err
+	}
+
+	return // Unsupported, e.g. margin info or balance updates
+}
@@ -0,0 +1,157 @@
+package ws
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// authenticated verifies an auth request signed with secret, confirming it
+// and returning true if the signature is valid.
+func (c *testConn) authenticated(msg map[string]interface{}, secret string) bool {
+	h := hmac.New(sha512.New384, []byte(secret))
+	h.Write([]byte(msg["authPayload"].(string)))
+
+	if msg["authSig"] != hex.EncodeToString(h.Sum(nil)) || msg["authPayload"] != "AUTH"+msg["authNonce"].(string) {
+		c.send(map[string]interface{}{"event": "auth", "status": "FAILED", "chanId": 0, "code": 10100, "msg": "apikey: invalid"})
+		return false
+	}
+
+	c.send(map[string]interface{}{"event": "auth", "status": "OK", "chanId": 0, "userId": 1})
+	return true
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "unauth":
+			conn.send(map[string]interface{}{"event": "unauth", "status": "OK", "chanId": 0})
+			return
+		case "auth":
+		default:
+			return
+		}
+
+		if !conn.authenticated(msg, "secret") {
+			return
+		}
+
+		conn.sendRaw(`[0,"ws",[["exchange","BTC",1.5,0,1.25,null],["funding","USD",1000,0,500,null]]]`)
+		conn.sendRaw(`[0,"wu",["funding","USD",1000,0,400,"Offer"]]`)
+		conn.sendRaw(`[0,"os",[]]`)
+		conn.sendRaw(`[0,"on",[10,0,5,"tBTCUSD",1500000000000,1500000000000,0.5,0.5,"EXCHANGE LIMIT",null,null,null,0,"ACTIVE",null,null,7000,0,0,0,null,null,null,0,0,null]]`)
+		conn.sendRaw(`[0,"fos",[[20,"fUSD",1500000000000,1500000000000,100,100,"LIMIT",null,null,0,"ACTIVE",null,null,null,0.0002,30,0,0,null,0]]]`)
+		conn.sendRaw(`[0,"foc",[20,"fUSD",1500000000000,1500000000001,0,100,"LIMIT",null,null,0,"EXECUTED at 0.02% (100.0)",null,null,null,0.0002,30,0,0,null,0]]`)
+		conn.sendRaw(`[0,"fcs",[]]`)
+		conn.sendRaw(`[0,"fls",[[30,"fUSD",1,1500000000000,1500000000001,100,0,"ACTIVE",null,null,null,0.0002,30,1500000000001,1500000000001,0,0,null,0,0,0]]]`)
+		conn.sendRaw(`[0,"te",[40,"tBTCUSD",1500000000002,10,0.5,7000,"EXCHANGE LIMIT",7000,1]]`)
+		conn.sendRaw(`[0,"fte",[41,"fUSD",1500000000001,20,100,0.0002,30,1]]`)
+		conn.sendRaw(`[0,"n",[1500000000003,"fon-req",null,null,[21,"fUSD"],null,"SUCCESS","Submitted"]]`)
+		conn.sendRaw(`[0,"hb"]`)
+		conn.sendRaw(`[0,"bu",[1000,1000]]`)
+		conn.sendRaw(`[0,"wu",["exchange","BTC",1.5,0,1.5,null]]`)
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	_, err := c.Authenticate(AuthRequest{APIKey: "key", APISecret: "wrong"})
+	if err == nil || err.Error() != "API: apikey: invalid" {
+		t.Errorf("Failed: expected authentication error, got %v", err)
+	}
+
+	sub, err := c.Authenticate(AuthRequest{APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	if _, err = c.Authenticate(AuthRequest{APIKey: "key", APISecret: "secret"}); err != ErrAuthenticated {
+		t.Errorf("Failed: expected ErrAuthenticated, got %v", err)
+	}
+
+	wallets, ok := receive(t, sub.Events).(WalletsSnapshot)
+	if !ok || len(wallets.Wallets) != 2 || wallets.Wallets[1].Type != "funding" || wallets.Wallets[0].Available != 1.25 {
+		t.Errorf("Failed: unexpected wallets %+v", wallets)
+	}
+
+	wallet, ok := receive(t, sub.Events).(WalletUpdate)
+	if !ok || wallet.Wallet.Available != 400 || wallet.Wallet.LastChange != "Offer" {
+		t.Errorf("Failed: unexpected wallet %+v", wallet)
+	}
+
+	orders, ok := receive(t, sub.Events).(OrdersSnapshot)
+	if !ok || len(orders.Orders) != 0 {
+		t.Errorf("Failed: unexpected orders %+v", orders)
+	}
+
+	order, ok := receive(t, sub.Events).(OrderEvent)
+	if !ok || order.Type != "on" || order.Order.ID != 10 || order.Order.CID != 5 || order.Order.Price != 7000 {
+		t.Errorf("Failed: unexpected order %+v", order)
+	}
+
+	offers, ok := receive(t, sub.Events).(FundingOffersSnapshot)
+	if !ok || len(offers.Offers) != 1 || offers.Offers[0].Rate != 0.0002 || offers.Offers[0].Period != 30 {
+		t.Errorf("Failed: unexpected offers %+v", offers)
+	}
+
+	offer, ok := receive(t, sub.Events).(FundingOfferEvent)
+	if !ok || offer.Type != "foc" || offer.Offer.ID != 20 || offer.Offer.Amount != 0 {
+		t.Errorf("Failed: unexpected offer %+v", offer)
+	}
+
+	if credits, ok := receive(t, sub.Events).(FundingCreditsSnapshot); !ok || len(credits.Credits) != 0 {
+		t.Errorf("Failed: unexpected credits %+v", credits)
+	}
+
+	loans, ok := receive(t, sub.Events).(FundingLoansSnapshot)
+	if !ok || len(loans.Loans) != 1 || loans.Loans[0].ID != 30 || loans.Loans[0].Side != 1 {
+		t.Errorf("Failed: unexpected loans %+v", loans)
+	}
+
+	trade, ok := receive(t, sub.Events).(AccountTradeEvent)
+	if !ok || trade.Type != "te" || trade.Trade.OrderID != 10 || trade.Trade.Maker != 1 {
+		t.Errorf("Failed: unexpected trade %+v", trade)
+	}
+
+	fundingTrade, ok := receive(t, sub.Events).(AccountFundingTradeEvent)
+	if !ok || fundingTrade.Trade.OfferID != 20 || !fundingTrade.Trade.Maker {
+		t.Errorf("Failed: unexpected funding trade %+v", fundingTrade)
+	}
+
+	n, ok := receive(t, sub.Events).(NotificationEvent)
+	if !ok || n.Notification.Type != "fon-req" || n.Notification.Status != "SUCCESS" {
+		t.Errorf("Failed: unexpected notification %+v", n)
+	}
+
+	// Heartbeats and unsupported messages are skipped
+	if wallet, ok := receive(t, sub.Events).(WalletUpdate); !ok || wallet.Wallet.Currency != "BTC" {
+		t.Errorf("Failed: unexpected wallet %+v", wallet)
+	}
+
+	err = sub.Unsubscribe()
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	select {
+	case _, ok := <-sub.Events:
+		if ok {
+			t.Error("Failed: expected events to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("Failed: events not closed")
+	}
+}
+
+func TestAuthenticateDeadMan(t *testing.T) {
+	if _, ok := authMessage(AuthRequest{APIKey: "key", APISecret: "secret"})["dms"]; ok {
+		t.Error("Failed: dead-man switch enabled by default")
+	}
+
+	if dms := authMessage(AuthRequest{APIKey: "key", APISecret: "secret", DeadMan: true})["dms"]; dms != 4 {
+		t.Errorf("Failed: expected dms 4, got %v", dms)
+	}
+}
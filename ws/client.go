@@ -0,0 +1,886 @@
+// Package ws implements a client for the Bitfinex WebSocket API, found at
+// https://docs.bitfinex.com/v2/docs/ws-general
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// URL points to Bitfinex WebSocket API URL
+	URL = "wss://api.bitfinex.com/ws/2"
+
+	// TICKER ...
+	TICKER = "ticker"
+	// TRADES ...
+	TRADES = "trades"
+	// BOOK ...
+	BOOK = "book"
+	// CANDLES ...
+	CANDLES = "candles"
+)
+
+const (
+	// CHECKSUM enables book checksum (ChecksumEvent) messages
+	CHECKSUM = 131072
+	// SEQ_ALL enables sequence numbers on all messages, which the client
+	// verifies, sending GapEvent on lost messages
+	SEQ_ALL = 65536
+)
+
+// Frequency ...
+type Frequency string
+
+const (
+	// F0 sends book updates in realtime
+	F0 Frequency = "F0"
+	// F1 sends book updates every 2 seconds
+	F1 Frequency = "F1"
+)
+
+var (
+	// ErrNotConnected is returned by requests sent while the client is not connected.
+	ErrNotConnected = errors.New("WS: Not connected")
+	// ErrTimeout is returned by requests the API did not respond to within Client.Timeout.
+	ErrTimeout = errors.New("WS: Request timed out")
+	// ErrPaused is returned by requests sent while the platform is in maintenance.
+	ErrPaused = errors.New("WS: Paused for maintenance")
+	// ErrGap is returned by Err when the connection was closed after lost
+	// messages, without Reconnect.
+	ErrGap = errors.New("WS: Messages lost")
+)
+
+// SubscribeRequest ...
+type SubscribeRequest struct {
+	Channel   string             // TICKER, TRADES, BOOK or CANDLES
+	Symbol    string             // Trading or funding symbol, e.g. "tBTCUSD" or "fUSD"
+	Precision bitfinex.Precision // Book precision, P0 to P4 or R0 for the raw book
+	Frequency Frequency          // Book update frequency, F0 or F1
+	Length    int                // Book length, 1, 25, 100 or 250 (0 for the API default)
+	Key       string             // Candles key, e.g. "trade:1m:tBTCUSD" or "trade:1m:fUSD:p30"
+
+	// Handler, if set, is called from the client's read loop with every
+	// event of the subscription instead of sending it to Subscription.Events.
+	Handler func(event interface{})
+}
+
+// Subscription is a channel subscribed to with Client.Subscribe.
+type Subscription struct {
+	Request SubscribeRequest
+	ChanID  int64 // Channel ID assigned by the API, 0 for Pool subscriptions, which may move between connections
+
+	// Events receives the events of the subscription, e.g. TickerEvent or
+	// BookSnapshot followed by BookUpdate, and is closed once unsubscribed or
+	// disconnected. After a reconnection, ResyncEvent is sent before the new
+	// snapshot. Events must be drained, the client blocks until each event is
+	// received, until Unsubscribe or Close drop the events not received.
+	Events <-chan interface{}
+
+	client     *Client
+	events     chan interface{}
+	subID      string
+	funding    bool
+	subscribed chan error
+	closed     chan struct{} // Closed once unsubscribed or disconnected
+	ending     chan struct{} // Closed by halt, stops delivering events
+	haltOnce   sync.Once     // Guards closing ending
+	resync     bool          // Resubscribing after a reconnection
+	cancelled  bool          // Unsubscribed while resubscribing
+	pooled     *pooled       // Pool state of Pool subscriptions
+}
+
+// Unsubscribe stops the subscription, waiting for the API to confirm it and
+// closing its Events channel.
+func (s *Subscription) Unsubscribe() error {
+	if s.pooled != nil {
+		return s.pooled.pool.unsubscribe(s)
+	}
+	return s.client.unsubscribe(s)
+}
+
+// halt stops delivering events to Events, so a subscription which is no
+// longer drained does not block the read loop while unsubscribing.
+func (s *Subscription) halt() {
+	s.haltOnce.Do(func() { close(s.ending) })
+}
+
+// end closes the subscription, called from the client's read loop.
+func (s *Subscription) end() {
+	if s.events != nil {
+		close(s.events)
+	}
+	close(s.closed)
+}
+
+// Client structure stores a Bitfinex WebSocket API connection and its subscriptions
+type Client struct {
+	URL     string        // WebSocket API URL, URL by default
+	Timeout time.Duration // How long requests wait for a response, 10 seconds by default
+	Flags   int           // Flags enabled by Connect, e.g. CHECKSUM, SEQ_ALL by default
+
+	// Reconnect, if true, reconnects after the connection is lost or the API
+	// asks to reconnect, re-authenticating and resubscribing all channels.
+	Reconnect  bool
+	Backoff    time.Duration // Delay before the first reconnection attempt, doubled after every failed attempt, 1 second by default
+	MaxBackoff time.Duration // Maximum delay between reconnection attempts, 1 minute by default
+
+	// Events receives connection level events, InfoEvent, ErrorEvent,
+	// DisconnectEvent and ReconnectEvent. Events which are not received in
+	// time are dropped.
+	Events <-chan interface{}
+
+	events    chan interface{}
+	conn      *websocket.Conn
+	done      chan struct{}
+	err       error
+	writeMu   sync.Mutex
+	mu        sync.Mutex
+	subs      map[int64]*Subscription  // Active subscriptions by channel ID
+	pending   map[string]*Subscription // Subscriptions awaiting response by subscription ID
+	auth      *Subscription            // Authentication awaiting response
+	nextID    int64
+	flags     int           // Flags enabled with Configure
+	confMu    sync.Mutex    // Serializes Configure
+	conf      chan error    // Configure awaiting response
+	confFlags int           // Flags of the Configure awaiting response
+	stop      chan struct{} // Closed by Close to stop reconnecting
+	closing   bool
+	paused    bool         // Platform in maintenance
+	authReq   *AuthRequest // Authentication to repeat after reconnecting
+
+	requests []*orderRequest // Order requests awaiting notification, oldest first
+	lastCID  int64           // Last client order ID, updated atomically
+
+	seq     int64 // Last public sequence number, 0 until received, if SEQ_ALL is enabled
+	authSeq int64 // Last authenticated sequence number
+	gap     bool  // Connection closed after a gap, without Reconnect
+}
+
+// New returns a new Bitfinex WebSocket API client
+func New() (c *Client) {
+	events := make(chan interface{}, 64)
+
+	c = &Client{
+		URL:        URL,
+		Timeout:    10 * time.Second,
+		Flags:      SEQ_ALL,
+		Backoff:    time.Second,
+		MaxBackoff: time.Minute,
+		Events:     events,
+		events:     events,
+		subs:       make(map[int64]*Subscription),
+		pending:    make(map[string]*Subscription),
+	}
+	return c
+}
+
+// Connect opens the connection to the WebSocket API and enables Flags, which
+// stay enabled after reconnecting.
+func (c *Client) Connect() (err error) {
+	conn, _, err := websocket.DefaultDialer.Dial(c.URL, nil)
+	if err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+
+	c.mu.Lock()
+	c.conn = conn
+	c.done = done
+	c.stop = make(chan struct{})
+	c.closing = false
+	c.paused = false
+	c.err = nil
+	c.flags = 0
+	c.mu.Unlock()
+
+	go c.listen(conn, done)
+
+	if c.Flags != 0 {
+		err = c.Configure(c.Flags)
+		if err != nil {
+			c.Close()
+		}
+	}
+	return
+}
+
+// Close closes the connection, closing the Events channels of all subscriptions.
+func (c *Client) Close() (err error) {
+	c.mu.Lock()
+	conn, done, stop, closing := c.conn, c.done, c.stop, c.closing
+	c.closing = true
+	for _, sub := range c.subs {
+		sub.halt() // Undrained subscriptions must not keep the read loop from closing
+	}
+	c.mu.Unlock()
+
+	if done == nil {
+		return ErrNotConnected
+	}
+
+	if !closing {
+		close(stop)
+		if conn != nil {
+			err = conn.Close()
+		}
+	}
+
+	<-done
+	return
+}
+
+// Done returns a channel which is closed when the connection is closed, or
+// lost without Reconnect.
+func (c *Client) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+
+// Err returns the error which closed the connection, if any.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Subscribe subscribes to a channel, waiting for the API to confirm the subscription.
+func (c *Client) Subscribe(request SubscribeRequest) (sub *Subscription, err error) {
+	if c.Paused() {
+		return nil, ErrPaused
+	}
+
+	if request.Symbol != "" {
+		request.Symbol = normalizeSymbol(request.Symbol)
+	}
+
+	c.mu.Lock()
+	c.nextID++
+	sub = &Subscription{
+		Request:    request,
+		client:     c,
+		subID:      strconv.FormatInt(c.nextID, 10),
+		funding:    isFundingRequest(request),
+		subscribed: make(chan error, 1),
+		closed:     make(chan struct{}),
+		ending:     make(chan struct{}),
+	}
+	if request.Handler == nil {
+		sub.events = make(chan interface{}, 256)
+		sub.Events = sub.events
+	}
+	c.pending[sub.subID] = sub
+	c.mu.Unlock()
+
+	err = c.send(subscribeMessage(sub))
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, sub.subID)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case err = <-sub.subscribed:
+	case <-time.After(c.Timeout):
+		err = ErrTimeout
+	}
+
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, sub.subID)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	return
+}
+
+// SubscribeTicker subscribes to the ticker of a trading or funding symbol.
+func (c *Client) SubscribeTicker(symbol string) (*Subscription, error) {
+	return c.Subscribe(SubscribeRequest{Channel: TICKER, Symbol: symbol})
+}
+
+// SubscribeTrades subscribes to the trades of a trading or funding symbol.
+func (c *Client) SubscribeTrades(symbol string) (*Subscription, error) {
+	return c.Subscribe(SubscribeRequest{Channel: TRADES, Symbol: symbol})
+}
+
+// SubscribeBook subscribes to the order book of a trading symbol or the
+// funding book of a funding symbol.
+func (c *Client) SubscribeBook(symbol string, precision bitfinex.Precision, frequency Frequency, length int) (*Subscription, error) {
+	return c.Subscribe(SubscribeRequest{Channel: BOOK, Symbol: symbol, Precision: precision, Frequency: frequency, Length: length})
+}
+
+// SubscribeCandles subscribes to the candles of a trading symbol, or funding
+// symbol with period, e.g. "fUSD:p30".
+func (c *Client) SubscribeCandles(symbol string, timeframe bitfinex.Timeframe) (*Subscription, error) {
+	if !isFundingSymbol(symbol) {
+		symbol = normalizeSymbol(symbol)
+	}
+	return c.Subscribe(SubscribeRequest{Channel: CANDLES, Key: "trade:" + string(timeframe) + ":" + symbol})
+}
+
+func (c *Client) unsubscribe(sub *Subscription) (err error) {
+	c.mu.Lock()
+	chanID := sub.ChanID
+	ok := c.subs[chanID] == sub
+	if !ok {
+		sub.cancelled = true // Unsubscribed once resubscribed, if reconnecting
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	sub.halt()
+
+	msg := map[string]interface{}{
+		"event":  "unsubscribe",
+		"chanId": chanID,
+	}
+	if sub.Request.Channel == ACCOUNT {
+		msg = map[string]interface{}{"event": "unauth"}
+	}
+
+	err = c.send(msg)
+	if err != nil {
+		return
+	}
+
+	select {
+	case <-sub.closed:
+	case <-time.After(c.Timeout):
+		err = ErrTimeout
+	}
+	return
+}
+
+// Configure enables connection flags, e.g. CHECKSUM, in addition to the
+// flags already enabled.
+func (c *Client) Configure(flags int) (err error) {
+	c.confMu.Lock()
+	defer c.confMu.Unlock()
+
+	conf := make(chan error, 1)
+
+	c.mu.Lock()
+	flags |= c.flags
+	c.conf = conf
+	c.confFlags = flags
+	c.mu.Unlock()
+
+	err = c.send(map[string]interface{}{
+		"event": "conf",
+		"flags": flags,
+	})
+	if err == nil {
+		select {
+		case err = <-conf:
+		case <-time.After(c.Timeout):
+			err = ErrTimeout
+		}
+	}
+
+	c.mu.Lock()
+	if c.conf == conf {
+		c.conf = nil
+	}
+	c.mu.Unlock()
+
+	return
+}
+
+///////////////////////////////////////
+// Connection handling
+///////////////////////////////////////
+
+func (c *Client) send(v interface{}) (err error) {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return ErrNotConnected
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return conn.WriteJSON(v)
+}
+
+func (c *Client) listen(conn *websocket.Conn, done chan struct{}) {
+	var err error
+	for {
+		var msg []byte
+		_, msg, err = conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if e := c.handleMessage(msg); e != nil {
+			c.emit(ErrorEvent{Msg: e.Error()})
+		}
+	}
+
+	c.mu.Lock()
+	subs, pending, auth, conf := c.subs, c.pending, c.auth, c.conf
+	c.subs = make(map[int64]*Subscription)
+	c.pending = make(map[string]*Subscription)
+	c.auth = nil
+	c.conf = nil
+	c.conn = nil
+	c.err = err
+	if c.gap {
+		c.err, c.gap = ErrGap, false
+	}
+	c.seq, c.authSeq = 0, 0
+	reconnect := c.Reconnect && !c.closing
+	c.mu.Unlock()
+
+	// Subscriptions still resubscribing after a previous reconnection are
+	// carried over to the next one
+	active := []*Subscription{}
+	for _, sub := range subs {
+		active = append(active, sub)
+	}
+	for _, sub := range pending {
+		if sub.resync {
+			active = append(active, sub)
+			continue
+		}
+		sub.subscribed <- ErrNotConnected
+	}
+	if auth != nil {
+		if auth.resync {
+			active = append(active, auth)
+		} else {
+			auth.subscribed <- ErrNotConnected
+		}
+	}
+	if conf != nil {
+		conf <- ErrNotConnected
+	}
+	c.failRequests()
+
+	if reconnect {
+		c.emit(DisconnectEvent{Err: err})
+		go c.reconnect(active, done)
+		return
+	}
+
+	for _, sub := range active {
+		sub.end()
+	}
+	close(done)
+}
+
+// emit sends a connection level event, dropping it if Events is full.
+func (c *Client) emit(event interface{}) {
+	select {
+	case c.events <- event:
+	default:
+	}
+}
+
+// deliver sends an event to the subscription.
+func (c *Client) deliver(sub *Subscription, event interface{}) {
+	if sub.Request.Handler != nil {
+		sub.Request.Handler(event)
+		return
+	}
+
+	select {
+	case sub.events <- event:
+	case <-sub.ending: // Unsubscribing, the event is dropped
+	}
+}
+
+///////////////////////////////////////
+// Message handling
+///////////////////////////////////////
+
+// event is the union of the fields of API event messages.
+type event struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	ChanID  int64  `json:"chanId"`
+	SubID   string `json:"subId"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+}
+
+func (c *Client) handleMessage(msg []byte) (err error) {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) == 0 {
+		return
+	}
+
+	if msg[0] == '{' {
+		return c.handleEvent(msg)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(msg))
+	decoder.UseNumber() // Keep IDs and millisecond timestamps exact
+
+	raw := []interface{}{}
+	err = decoder.Decode(&raw)
+	if err != nil {
+		return
+	}
+
+	return c.handleChannel(c.sequence(raw))
+}
+
+func (c *Client) handleEvent(msg []byte) (err error) {
+	e := event{}
+	err = json.Unmarshal(msg, &e)
+	if err != nil {
+		return
+	}
+
+	switch e.Event {
+	case "info":
+		info := InfoEvent{}
+		err = json.Unmarshal(msg, &info)
+		if err != nil {
+			return
+		}
+		c.emit(info)
+		c.handleInfo(info)
+
+	case "subscribed":
+		c.mu.Lock()
+		sub := c.pending[e.SubID]
+		resync := false
+		if sub != nil {
+			delete(c.pending, e.SubID)
+			sub.ChanID = e.ChanID
+			c.subs[e.ChanID] = sub
+			resync, sub.resync = sub.resync, false
+		}
+		c.mu.Unlock()
+
+		if sub == nil {
+			return
+		}
+		if resync && sub.cancelled {
+			return c.send(map[string]interface{}{"event": "unsubscribe", "chanId": e.ChanID})
+		}
+		if resync {
+			c.deliver(sub, ResyncEvent{})
+			return
+		}
+		sub.subscribed <- nil
+
+	case "auth":
+		c.handleAuth(e)
+
+	case "conf":
+		c.mu.Lock()
+		conf := c.conf
+		c.conf = nil
+		if conf != nil && e.Status == "OK" {
+			c.flags = c.confFlags // Set from the read loop, messages which follow depend on it
+		}
+		c.mu.Unlock()
+
+		if conf == nil {
+			return
+		}
+		if e.Status != "OK" {
+			conf <- errors.New("API: " + e.Msg)
+			return
+		}
+		conf <- nil
+
+	case "unsubscribed", "unauth":
+		c.mu.Lock()
+		sub := c.subs[e.ChanID]
+		delete(c.subs, e.ChanID)
+		if sub != nil && sub.Request.Channel == ACCOUNT {
+			c.authReq = nil
+		}
+		c.mu.Unlock()
+
+		if sub != nil {
+			sub.end()
+		}
+
+	case "error":
+		c.mu.Lock()
+		sub := c.pending[e.SubID]
+		delete(c.pending, e.SubID)
+		c.mu.Unlock()
+
+		if sub != nil && !sub.resync {
+			sub.subscribed <- errors.New("API: " + e.Msg)
+			return
+		}
+		if sub != nil {
+			sub.end() // Resubscription failed
+		}
+		c.emit(ErrorEvent{Code: e.Code, Msg: e.Msg})
+	}
+
+	return
+}
+
+// handleChannel handles [CHANNEL_ID, ...] channel messages.
+func (c *Client) handleChannel(raw []interface{}) (err error) {
+	if len(raw) < 2 {
+		return
+	}
+
+	chanID, err := toInt64(raw[0])
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	sub := c.subs[chanID]
+	c.mu.Unlock()
+
+	if sub == nil {
+		return
+	}
+
+	var e interface{}
+	switch payload := raw[1].(type) {
+	case string:
+		if len(raw) < 3 {
+			return // Heartbeat
+		}
+		if payload == "cs" {
+			checksum := ChecksumEvent{}
+			err = bitfinex.DecodeArray([]interface{}{raw[2]}, &checksum)
+			e = checksum
+			break
+		}
+		if sub.Request.Channel == ACCOUNT {
+			e, err = decodeAccount(payload, raw[2])
+			if n, ok := e.(NotificationEvent); ok {
+				c.handleNotification(n.Notification)
+			}
+			break
+		}
+		e, err = decodeTrade(sub, payload, raw[2])
+
+	case []interface{}:
+		e, err = decodeChannel(sub, payload)
+	}
+
+	if err != nil || e == nil {
+		return
+	}
+
+	c.deliver(sub, e)
+	return
+}
+
+// decodeTrade decodes [CHANNEL_ID, "te", [TRADE]] trade messages.
+func decodeTrade(sub *Subscription, kind string, raw interface{}) (e interface{}, err error) {
+	switch kind {
+	case "te", "tu":
+		trade := TradeEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &trade.Trade)
+		return trade, err
+
+	case "fte", "ftu":
+		trade := FundingTradeEvent{Type: kind}
+		err = bitfinex.DecodeArray(raw, &trade.Trade)
+		return trade, err
+	}
+
+	return
+}
+
+// decodeChannel decodes [CHANNEL_ID, [...]] snapshot and update messages.
+func decodeChannel(sub *Subscription, data []interface{}) (e interface{}, err error) {
+	snapshot := len(data) == 0
+	if len(data) > 0 {
+		_, snapshot = data[0].([]interface{})
+	}
+
+	switch sub.Request.Channel {
+	case TICKER:
+		if sub.funding {
+			ticker := FundingTickerEvent{}
+			err = bitfinex.DecodeArray(data, &ticker.Ticker)
+			ticker.Ticker.Symbol = sub.Request.Symbol
+			return ticker, err
+		}
+
+		ticker := TickerEvent{}
+		err = bitfinex.DecodeArray(data, &ticker.Ticker)
+		ticker.Ticker.Symbol = sub.Request.Symbol
+		return ticker, err
+
+	case TRADES:
+		if !snapshot {
+			return
+		}
+
+		if sub.funding {
+			trades := FundingTradesSnapshot{}
+			err = bitfinex.DecodeArray(data, &trades.Trades)
+			return trades, err
+		}
+
+		trades := TradesSnapshot{}
+		err = bitfinex.DecodeArray(data, &trades.Trades)
+		return trades, err
+
+	case BOOK:
+		if sub.funding {
+			return decodeFundingBook(sub.Request.Precision, snapshot, data)
+		}
+		return decodeBook(sub.Request.Precision, snapshot, data)
+
+	case CANDLES:
+		if snapshot {
+			candles := CandlesSnapshot{}
+			err = bitfinex.DecodeArray(data, &candles.Candles)
+			return candles, err
+		}
+
+		candle := CandleUpdate{}
+		err = bitfinex.DecodeArray(data, &candle.Candle)
+		return candle, err
+	}
+
+	return
+}
+
+func decodeBook(precision bitfinex.Precision, snapshot bool, data []interface{}) (e interface{}, err error) {
+	if precision != bitfinex.R0 {
+		if snapshot {
+			book := BookSnapshot{}
+			err = bitfinex.DecodeArray(data, &book.Entries)
+			return book, err
+		}
+
+		update := BookUpdate{}
+		err = bitfinex.DecodeArray(data, &update.Entry)
+		return update, err
+	}
+
+	if snapshot {
+		entries := []rawBookEntry{}
+		err = bitfinex.DecodeArray(data, &entries)
+
+		book := BookSnapshot{Entries: []bitfinex.BookEntry{}}
+		for _, r := range entries {
+			book.Entries = append(book.Entries, bitfinex.BookEntry{ID: r.ID, Price: r.Price, Amount: r.Amount})
+		}
+		return book, err
+	}
+
+	r := rawBookEntry{}
+	err = bitfinex.DecodeArray(data, &r)
+	return BookUpdate{Entry: bitfinex.BookEntry{ID: r.ID, Price: r.Price, Amount: r.Amount}}, err
+}
+
+func decodeFundingBook(precision bitfinex.Precision, snapshot bool, data []interface{}) (e interface{}, err error) {
+	if precision != bitfinex.R0 {
+		if snapshot {
+			book := FundingBookSnapshot{}
+			err = bitfinex.DecodeArray(data, &book.Entries)
+			return book, err
+		}
+
+		update := FundingBookUpdate{}
+		err = bitfinex.DecodeArray(data, &update.Entry)
+		return update, err
+	}
+
+	if snapshot {
+		entries := []rawFundingBookEntry{}
+		err = bitfinex.DecodeArray(data, &entries)
+
+		book := FundingBookSnapshot{Entries: []bitfinex.FundingBookEntry{}}
+		for _, r := range entries {
+			book.Entries = append(book.Entries, bitfinex.FundingBookEntry{ID: r.ID, Rate: r.Rate, Period: r.Period, Amount: r.Amount})
+		}
+		return book, err
+	}
+
+	r := rawFundingBookEntry{}
+	err = bitfinex.DecodeArray(data, &r)
+	return FundingBookUpdate{Entry: bitfinex.FundingBookEntry{ID: r.ID, Rate: r.Rate, Period: r.Period, Amount: r.Amount}}, err
+}
+
+///////////////////////////////////////
+// Helpers
+///////////////////////////////////////
+
+func subscribeMessage(sub *Subscription) map[string]interface{} {
+	request := sub.Request
+	msg := map[string]interface{}{
+		"event":   "subscribe",
+		"channel": request.Channel,
+		"subId":   sub.subID,
+	}
+
+	if request.Symbol != "" {
+		msg["symbol"] = request.Symbol
+	}
+	if request.Key != "" {
+		msg["key"] = request.Key
+	}
+	if request.Precision != "" {
+		msg["prec"] = string(request.Precision)
+	}
+	if request.Frequency != "" {
+		msg["freq"] = string(request.Frequency)
+	}
+	if request.Length != 0 {
+		msg["len"] = strconv.Itoa(request.Length)
+	}
+
+	return msg
+}
+
+// isFundingRequest returns true if the request subscribes to funding data,
+// i.e. to a funding symbol or funding candles key ("trade:1m:fUSD:p30").
+func isFundingRequest(request SubscribeRequest) bool {
+	if request.Key != "" {
+		parts := strings.Split(request.Key, ":")
+		return len(parts) > 2 && isFundingSymbol(parts[2])
+	}
+	return isFundingSymbol(request.Symbol)
+}
+
+func isFundingSymbol(symbol string) bool {
+	return len(symbol) > 1 && symbol[0] == 'f' && symbol[1] >= 'A' && symbol[1] <= 'Z'
+}
+
+func normalizeSymbol(symbol string) string {
+	if len(symbol) > 1 && (symbol[0] == 't' || symbol[0] == 'f') && symbol[1] >= 'A' && symbol[1] <= 'Z' { // Already a symbol, e.g. "tBTCUSD"
+		return symbol
+	}
+	return "t" + strings.ToUpper(symbol)
+}
+
+func toInt64(raw interface{}) (int64, error) {
+	switch r := raw.(type) {
+	case json.Number:
+		return r.Int64()
+	case float64:
+		return int64(r), nil
+	}
+
+	return 0, errors.New("WS: Unexpected channel ID")
+}
@@ -0,0 +1,509 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a local stand-in for the WebSocket API. It sends the info
+// event on connect and passes every message received to handle.
+type testServer struct {
+	*httptest.Server
+	URL string
+
+	mu     sync.Mutex
+	conns  []*testConn
+	handle func(conn *testConn, msg map[string]interface{})
+
+	// input, if set, is called with [0, TYPE, null, PAYLOAD] input messages
+	input func(conn *testConn, msg []interface{})
+}
+
+type testConn struct {
+	*websocket.Conn
+	mu     sync.Mutex
+	chanID int64
+}
+
+func (c *testConn) send(v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.WriteJSON(v)
+}
+
+func (c *testConn) sendRaw(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+// subscribed confirms a subscribe request, returning the assigned channel ID.
+func (c *testConn) subscribed(msg map[string]interface{}) int64 {
+	c.mu.Lock()
+	c.chanID++
+	chanID := c.chanID
+	c.mu.Unlock()
+
+	reply := map[string]interface{}{}
+	for k, v := range msg {
+		reply[k] = v
+	}
+	reply["event"] = "subscribed"
+	reply["chanId"] = chanID
+	c.send(reply)
+
+	return chanID
+}
+
+func newTestServer(t *testing.T, handle func(conn *testConn, msg map[string]interface{})) (s *testServer) {
+	s = &testServer{handle: handle}
+	upgrader := websocket.Upgrader{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error("Failed: " + err.Error())
+			return
+		}
+		conn := &testConn{Conn: ws}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		conn.send(map[string]interface{}{"event": "info", "version": 2, "platform": map[string]interface{}{"status": 1}})
+
+		for {
+			var msg interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+
+			switch m := msg.(type) {
+			case map[string]interface{}:
+				s.handle(conn, m)
+			case []interface{}:
+				if s.input != nil {
+					s.input(conn, m)
+				}
+			}
+		}
+	}))
+	s.URL = "ws" + strings.TrimPrefix(s.Server.URL, "http")
+
+	return
+}
+
+// broadcast sends v on all connections accepted by the server.
+func (s *testServer) broadcast(v interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.send(v)
+	}
+}
+
+// drop closes all connections accepted by the server.
+func (s *testServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func newTestClient(t *testing.T, s *testServer) *Client {
+	c := New()
+	c.URL = s.URL
+	c.Timeout = time.Second
+	c.Flags = 0 // Test servers do not send sequence numbers
+
+	if err := c.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	return c
+}
+
+func receive(t *testing.T, events <-chan interface{}) interface{} {
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("Failed: events closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("Failed: no event received")
+	}
+	return nil
+}
+
+func TestInfoEvent(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	info, ok := receive(t, c.Events).(InfoEvent)
+	if !ok || info.Version != 2 || info.Platform.Status != 1 {
+		t.Errorf("Failed: unexpected info event %+v", info)
+	}
+}
+
+func TestSubscribeTicker(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		if msg["event"] != "subscribe" || msg["channel"] != TICKER {
+			return
+		}
+
+		chanID := conn.subscribed(msg)
+		if msg["symbol"] == "fUSD" {
+			conn.send([]interface{}{chanID, []interface{}{0.0002, 0.00019, 30, 1000, 0.00021, 2, 2000, 0, 0, 0.0002, 5000000, 0.0003, 0.0001}})
+			return
+		}
+
+		conn.send([]interface{}{chanID, "hb"})
+		conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	sub, err := c.SubscribeTicker("btcusd")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	if sub.Request.Symbol != "tBTCUSD" || sub.ChanID == 0 {
+		t.Errorf("Failed: unexpected subscription %+v", sub)
+	}
+
+	ticker, ok := receive(t, sub.Events).(TickerEvent)
+	if !ok || ticker.Ticker.Symbol != "tBTCUSD" || ticker.Ticker.Bid != 7000 || ticker.Ticker.LastPrice != 7000.5 {
+		t.Errorf("Failed: unexpected ticker %+v", ticker)
+	}
+
+	sub, err = c.SubscribeTicker("fUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	funding, ok := receive(t, sub.Events).(FundingTickerEvent)
+	if !ok || funding.Ticker.Symbol != "fUSD" || funding.Ticker.FRR != 0.0002 || funding.Ticker.BidPeriod != 30 {
+		t.Errorf("Failed: unexpected funding ticker %+v", funding)
+	}
+}
+
+func TestSubscribeTrades(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		chanID := conn.subscribed(msg)
+		conn.send([]interface{}{chanID, []interface{}{
+			[]interface{}{2, 1500000000001, 0.5, 7000},
+			[]interface{}{1, 1500000000000, -0.25, 6999},
+		}})
+		conn.sendRaw(`[` + strconv.FormatInt(chanID, 10) + `,"te",[3,1500000000002,1.5,7001]]`)
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	sub, err := c.SubscribeTrades("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	snapshot, ok := receive(t, sub.Events).(TradesSnapshot)
+	if !ok || len(snapshot.Trades) != 2 || snapshot.Trades[1].Amount != -0.25 {
+		t.Errorf("Failed: unexpected snapshot %+v", snapshot)
+	}
+
+	trade, ok := receive(t, sub.Events).(TradeEvent)
+	if !ok || trade.Type != "te" || trade.Trade.ID != 3 || trade.Trade.Created != 1500000000002 || trade.Trade.Price != 7001 {
+		t.Errorf("Failed: unexpected trade %+v", trade)
+	}
+}
+
+func TestSubscribeBook(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		if msg["len"] != "25" || msg["freq"] != string(F0) {
+			conn.send(map[string]interface{}{"event": "error", "msg": "invalid request", "code": 10300, "subId": msg["subId"]})
+			return
+		}
+
+		chanID := conn.subscribed(msg)
+		if msg["prec"] == string(bitfinex.R0) {
+			conn.send([]interface{}{chanID, []interface{}{
+				[]interface{}{101, 7000, 1},
+				[]interface{}{102, 7001, -2},
+			}})
+			conn.send([]interface{}{chanID, []interface{}{101, 0, 1}})
+			return
+		}
+
+		conn.send([]interface{}{chanID, []interface{}{
+			[]interface{}{7000, 2, 1.5},
+			[]interface{}{7001, 1, -2},
+		}})
+		conn.send([]interface{}{chanID, []interface{}{7000, 0, 1}})
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	sub, err := c.SubscribeBook("tBTCUSD", bitfinex.P0, F0, 25)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	snapshot, ok := receive(t, sub.Events).(BookSnapshot)
+	if !ok || len(snapshot.Entries) != 2 || snapshot.Entries[0].Count != 2 || snapshot.Entries[1].Amount != -2 {
+		t.Errorf("Failed: unexpected snapshot %+v", snapshot)
+	}
+
+	update, ok := receive(t, sub.Events).(BookUpdate)
+	if !ok || update.Entry.Price != 7000 || update.Entry.Count != 0 {
+		t.Errorf("Failed: unexpected update %+v", update)
+	}
+
+	sub, err = c.SubscribeBook("tBTCUSD", bitfinex.R0, F0, 25)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	snapshot, ok = receive(t, sub.Events).(BookSnapshot)
+	if !ok || len(snapshot.Entries) != 2 || snapshot.Entries[1].ID != 102 || snapshot.Entries[1].Price != 7001 {
+		t.Errorf("Failed: unexpected raw snapshot %+v", snapshot)
+	}
+
+	update, ok = receive(t, sub.Events).(BookUpdate)
+	if !ok || update.Entry.ID != 101 || update.Entry.Price != 0 {
+		t.Errorf("Failed: unexpected raw update %+v", update)
+	}
+
+	_, err = c.SubscribeBook("tBTCUSD", bitfinex.P0, F1, 100)
+	if err == nil || err.Error() != "API: invalid request" {
+		t.Errorf("Failed: expected subscription error, got %v", err)
+	}
+}
+
+func TestSubscribeCandles(t *testing.T) {
+	var key interface{}
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		key = msg["key"]
+		chanID := conn.subscribed(msg)
+		conn.send([]interface{}{chanID, []interface{}{
+			[]interface{}{1500000060000, 7000, 7010, 7020, 6990, 12},
+		}})
+		conn.send([]interface{}{chanID, []interface{}{1500000060000, 7000, 7015, 7020, 6990, 13}})
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	sub, err := c.SubscribeCandles("BTCUSD", bitfinex.OneMinute)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	if key != "trade:1m:tBTCUSD" {
+		t.Errorf("Failed: unexpected key %v", key)
+	}
+
+	snapshot, ok := receive(t, sub.Events).(CandlesSnapshot)
+	if !ok || len(snapshot.Candles) != 1 || snapshot.Candles[0].MTS != 1500000060000 {
+		t.Errorf("Failed: unexpected snapshot %+v", snapshot)
+	}
+
+	update, ok := receive(t, sub.Events).(CandleUpdate)
+	if !ok || update.Candle.Close != 7015 || update.Candle.Volume != 13 {
+		t.Errorf("Failed: unexpected update %+v", update)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "subscribe":
+			conn.subscribed(msg)
+		case "unsubscribe":
+			conn.send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+		}
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	sub, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	err = sub.Unsubscribe()
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	select {
+	case _, ok := <-sub.Events:
+		if ok {
+			t.Error("Failed: expected events to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("Failed: events not closed")
+	}
+}
+
+func TestUnsubscribeUndrained(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "subscribe":
+			chanID := conn.subscribed(msg)
+			for i := 0; i < 300; i++ {
+				conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+			}
+		case "unsubscribe":
+			conn.send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+		}
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+
+	sub, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	time.Sleep(100 * time.Millisecond) // Events fill up
+
+	// Unsubscribing drops the events not received instead of blocking the connection
+	if err = sub.Unsubscribe(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	other, err := c.SubscribeTicker("tETHUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Closing does not wait for undrained subscriptions either
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Failed: client not closed")
+	}
+
+	for range other.Events {
+		// Drained until closed
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		chanID := conn.subscribed(msg)
+		conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	events := make(chan interface{}, 1)
+	sub, err := c.Subscribe(SubscribeRequest{Channel: TICKER, Symbol: "tBTCUSD", Handler: func(e interface{}) { events <- e }})
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	if sub.Events != nil {
+		t.Error("Failed: expected no events channel with a handler")
+	}
+
+	if _, ok := receive(t, events).(TickerEvent); !ok {
+		t.Error("Failed: expected ticker event")
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		conn.subscribed(msg)
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+
+	sub, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	s.drop()
+
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Failed: disconnect not detected")
+	}
+
+	if _, ok := <-sub.Events; ok {
+		t.Error("Failed: expected events to be closed")
+	}
+	if c.Err() == nil {
+		t.Error("Failed: expected connection error")
+	}
+
+	if _, err = c.SubscribeTicker("tBTCUSD"); err != ErrNotConnected {
+		t.Errorf("Failed: expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "conf":
+			conn.send(map[string]interface{}{"event": "conf", "status": "OK", "flags": msg["flags"]})
+		case "subscribe":
+			chanID := conn.subscribed(msg)
+			conn.send([]interface{}{chanID, []interface{}{[]interface{}{7000, 1, 1}}})
+			conn.send([]interface{}{chanID, "cs", -1234567890})
+		}
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	err := c.Configure(CHECKSUM)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	sub, err := c.SubscribeBook("tBTCUSD", bitfinex.P0, F0, 25)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	receive(t, sub.Events)
+	checksum, ok := receive(t, sub.Events).(ChecksumEvent)
+	if !ok || checksum.Checksum != -1234567890 {
+		t.Errorf("Failed: unexpected checksum %+v", checksum)
+	}
+}
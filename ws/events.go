@@ -0,0 +1,240 @@
+package ws
+
+import (
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// InfoEvent ...
+type InfoEvent struct {
+	Version  int    `json:"version"`  // API version, sent on connect
+	ServerID string `json:"serverId"` // Server ID, sent on connect
+	Code     int    `json:"code"`     // Info code, e.g. 20051 (reconnect), 20060 (maintenance start), 20061 (maintenance end)
+	Msg      string `json:"msg"`      // Info message
+	Platform struct {
+		Status int `json:"status"` // 1 if operative, 0 if in maintenance
+	} `json:"platform"`
+}
+
+// ErrorEvent ...
+type ErrorEvent struct {
+	Code int    `json:"code"` // Error code, e.g. 10300 (subscription failed)
+	Msg  string `json:"msg"`  // Error message
+}
+
+// DisconnectEvent is sent when the connection is lost and the client starts
+// reconnecting.
+type DisconnectEvent struct {
+	Err error // Error which closed the connection
+}
+
+// ReconnectEvent is sent when the client has reconnected, after repeating
+// authentication and subscriptions.
+type ReconnectEvent struct {
+	Attempts int // Number of connection attempts
+}
+
+// GapEvent is sent when a sequence number is skipped, i.e. messages were
+// lost, if SEQ_ALL is enabled. With Reconnect, the client reconnects to
+// resync all channels. Without, the connection is closed, as its state is
+// stale: Done is closed and Err returns ErrGap.
+type GapEvent struct {
+	Authenticated bool  // Gap in the sequence of account messages
+	Expected      int64 // Sequence number expected
+	Received      int64 // Sequence number received
+}
+
+// ResyncEvent is sent on subscriptions resubscribed after a reconnection.
+// State built from earlier events should be discarded, the channel sends a
+// new snapshot.
+type ResyncEvent struct{}
+
+// TickerEvent ...
+type TickerEvent struct {
+	Ticker bitfinex.TradingTicker
+}
+
+// FundingTickerEvent ...
+type FundingTickerEvent struct {
+	Ticker bitfinex.FundingTicker
+}
+
+// TradesSnapshot ...
+type TradesSnapshot struct {
+	Trades bitfinex.Trades // Most recent trades, most recent first
+}
+
+// TradeEvent ...
+type TradeEvent struct {
+	Type  string // "te" when executed, "tu" when updated with the trade ID
+	Trade bitfinex.Trade
+}
+
+// FundingTrade ...
+type FundingTrade struct {
+	ID      int     `bfx:"0"` // Trade ID
+	Created int64   `bfx:"1"` // Millisecond timestamp of execution
+	Amount  float64 `bfx:"2"` // Amount lent (positive) or borrowed (negative)
+	Rate    float64 `bfx:"3"` // Rate per day at which the funding was executed
+	Period  int     `bfx:"4"` // Period in days
+}
+
+// FundingTradesSnapshot ...
+type FundingTradesSnapshot struct {
+	Trades []FundingTrade // Most recent funding trades, most recent first
+}
+
+// FundingTradeEvent ...
+type FundingTradeEvent struct {
+	Type  string // "fte" when executed, "ftu" when updated with the trade ID
+	Trade FundingTrade
+}
+
+// BookSnapshot ...
+type BookSnapshot struct {
+	Entries []bitfinex.BookEntry
+}
+
+// BookUpdate is a change of a price level (aggregated books) or an order (raw
+// books). Levels with a zero Count and orders with a zero Price are removed.
+type BookUpdate struct {
+	Entry bitfinex.BookEntry
+}
+
+// FundingBookSnapshot ...
+type FundingBookSnapshot struct {
+	Entries []bitfinex.FundingBookEntry
+}
+
+// FundingBookUpdate is a change of a rate level (aggregated books) or an offer
+// (raw books). Levels with a zero Count and offers with a zero Rate are removed.
+type FundingBookUpdate struct {
+	Entry bitfinex.FundingBookEntry
+}
+
+// ChecksumEvent is sent on book channels after every update once CHECKSUM
+// is enabled with Client.Configure.
+type ChecksumEvent struct {
+	Checksum int32 `bfx:"0"` // CRC32 checksum of the top 25 bids and asks
+}
+
+// CandlesSnapshot ...
+type CandlesSnapshot struct {
+	Candles bitfinex.Candles // Most recent candles, most recent first
+}
+
+// CandleUpdate ...
+type CandleUpdate struct {
+	Candle bitfinex.Candle
+}
+
+// rawBookEntry is a raw (R0) order book entry.
+type rawBookEntry struct {
+	ID     int     `bfx:"0"`
+	Price  float64 `bfx:"1"`
+	Amount float64 `bfx:"2"`
+}
+
+// rawFundingBookEntry is a raw (R0) funding book entry.
+type rawFundingBookEntry struct {
+	ID     int     `bfx:"0"`
+	Period int     `bfx:"1"`
+	Rate   float64 `bfx:"2"`
+	Amount float64 `bfx:"3"`
+}
+
+// NotificationEvent ...
+type NotificationEvent struct {
+	Notification bitfinex.Notification
+}
+
+// WalletsSnapshot ...
+type WalletsSnapshot struct {
+	Wallets bitfinex.Wallets
+}
+
+// WalletUpdate ...
+type WalletUpdate struct {
+	Wallet bitfinex.Wallet
+}
+
+// OrdersSnapshot ...
+type OrdersSnapshot struct {
+	Orders bitfinex.Orders // Active orders
+}
+
+// OrderEvent ...
+type OrderEvent struct {
+	Type  string // "on" when created, "ou" when updated, "oc" when cancelled or fully executed
+	Order bitfinex.Order
+}
+
+// PositionsSnapshot ...
+type PositionsSnapshot struct {
+	Positions bitfinex.Positions // Active positions
+}
+
+// PositionEvent ...
+type PositionEvent struct {
+	Type     string // "pn" when opened, "pu" when updated, "pc" when closed
+	Position bitfinex.Position
+}
+
+// FundingOffersSnapshot ...
+type FundingOffersSnapshot struct {
+	Offers bitfinex.FundingOffers // Active funding offers
+}
+
+// FundingOfferEvent ...
+type FundingOfferEvent struct {
+	Type  string // "fon" when created, "fou" when updated, "foc" when cancelled or fully executed
+	Offer bitfinex.FundingOffer
+}
+
+// FundingCreditsSnapshot ...
+type FundingCreditsSnapshot struct {
+	Credits bitfinex.FundingCredits // Active funding used in positions
+}
+
+// FundingCreditEvent ...
+type FundingCreditEvent struct {
+	Type   string // "fcn" when opened, "fcu" when updated, "fcc" when closed
+	Credit bitfinex.FundingCredit
+}
+
+// FundingLoansSnapshot ...
+type FundingLoansSnapshot struct {
+	Loans bitfinex.FundingCredits // Active funding not used in positions
+}
+
+// FundingLoanEvent ...
+type FundingLoanEvent struct {
+	Type string // "fln" when opened, "flu" when updated, "flc" when closed
+	Loan bitfinex.FundingCredit
+}
+
+// AccountTrade is a trade executed on one of your orders.
+type AccountTrade struct {
+	ID          int     `bfx:"0"`  // Trade ID
+	Symbol      string  `bfx:"1"`  // Trading symbol, e.g. "tBTCUSD"
+	Created     int64   `bfx:"2"`  // Millisecond timestamp of execution
+	OrderID     int     `bfx:"3"`  // ID of the order which was executed
+	Amount      float64 `bfx:"4"`  // Amount bought (positive) or sold (negative)
+	Price       float64 `bfx:"5"`  // Price at which the trade was executed
+	OrderType   string  `bfx:"6"`  // Type of the order which was executed
+	OrderPrice  float64 `bfx:"7"`  // Price of the order which was executed
+	Maker       int     `bfx:"8"`  // 1 if the order was the maker, -1 if the taker
+	Fee         float64 `bfx:"9"`  // Fee paid, "tu" events only
+	FeeCurrency string  `bfx:"10"` // Currency of the fee, "tu" events only
+}
+
+// AccountTradeEvent ...
+type AccountTradeEvent struct {
+	Type  string // "te" when executed, "tu" when updated with the trade ID and fee
+	Trade AccountTrade
+}
+
+// AccountFundingTradeEvent ...
+type AccountFundingTradeEvent struct {
+	Type  string // "fte" when executed, "ftu" when updated with the trade ID
+	Trade bitfinex.FundingTrade
+}
@@ -0,0 +1,250 @@
+package ws
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// ErrNotAuthenticated is returned by order requests sent before Authenticate.
+var ErrNotAuthenticated = errors.New("WS: Not authenticated")
+
+// RequestError is returned by order requests which the API rejected.
+type RequestError struct {
+	Type   string // Request type, e.g. "on-req", "ou-req", "oc-req" or "oc_multi-req"
+	Status string // "ERROR" or "FAILURE"
+	Text   string // Reason, e.g. "Invalid order: not enough exchange balance"
+}
+
+func (e *RequestError) Error() string {
+	return "API: " + e.Text
+}
+
+// OrderUpdate ...
+type OrderUpdate struct {
+	ID            int     // ID of the order to update
+	Amount        float64 // New amount, positive to buy, negative to sell (0 to keep)
+	Delta         float64 // Change of the amount, instead of Amount (0 to keep)
+	Price         float64 // New price (0 to keep)
+	PriceTrailing float64 // New trailing price (0 to keep)
+	PriceAuxLimit float64 // New auxiliary limit price (0 to keep)
+	Flags         int     // New flags (0 to keep)
+}
+
+// SubmitOrder submits an order on the authenticated connection, waiting for
+// its notification. A client order ID (CID) is assigned if not set.
+func (c *Client) SubmitOrder(order bitfinex.OrderRequest) (submitted bitfinex.Order, err error) {
+	if order.CID == 0 {
+		order.CID = c.newCID()
+	}
+
+	request := struct {
+		GID           int     `json:"gid,omitempty"`
+		CID           int     `json:"cid"`
+		Type          string  `json:"type"`
+		Symbol        string  `json:"symbol"`
+		Amount        float64 `json:"amount,string"`
+		Price         float64 `json:"price,string"`
+		PriceTrailing float64 `json:"price_trailing,string,omitempty"`
+		PriceAuxLimit float64 `json:"price_aux_limit,string,omitempty"`
+		Flags         int     `json:"flags,omitempty"`
+	}{
+		GID:           order.GID,
+		CID:           order.CID,
+		Type:          strings.ToUpper(order.Type),
+		Symbol:        normalizeSymbol(order.Symbol),
+		Amount:        order.Amount,
+		Price:         order.Price,
+		PriceTrailing: order.PriceTrailing,
+		PriceAuxLimit: order.PriceAuxLimit,
+		Flags:         order.Flags,
+	}
+
+	err = c.request("on", "on:"+strconv.Itoa(order.CID), request, &submitted)
+	return
+}
+
+// UpdateOrder updates an active order, waiting for its notification.
+func (c *Client) UpdateOrder(update OrderUpdate) (updated bitfinex.Order, err error) {
+	request := struct {
+		ID            int     `json:"id"`
+		Amount        float64 `json:"amount,string,omitempty"`
+		Delta         float64 `json:"delta,string,omitempty"`
+		Price         float64 `json:"price,string,omitempty"`
+		PriceTrailing float64 `json:"price_trailing,string,omitempty"`
+		PriceAuxLimit float64 `json:"price_aux_limit,string,omitempty"`
+		Flags         int     `json:"flags,omitempty"`
+	}{
+		ID:            update.ID,
+		Amount:        update.Amount,
+		Delta:         update.Delta,
+		Price:         update.Price,
+		PriceTrailing: update.PriceTrailing,
+		PriceAuxLimit: update.PriceAuxLimit,
+		Flags:         update.Flags,
+	}
+
+	err = c.request("ou", "ou:"+strconv.Itoa(update.ID), request, &updated)
+	return
+}
+
+// CancelOrder cancels an active order, waiting for its notification.
+func (c *Client) CancelOrder(id int) (cancelled bitfinex.Order, err error) {
+	request := struct {
+		ID int `json:"id"`
+	}{
+		id,
+	}
+
+	err = c.request("oc", "oc:"+strconv.Itoa(id), request, &cancelled)
+	return
+}
+
+// CancelOrders cancels several active orders at once, waiting for the
+// notification of the request.
+func (c *Client) CancelOrders(ids ...int) (err error) {
+	request := struct {
+		ID []int `json:"id"`
+	}{
+		ids,
+	}
+
+	return c.request("oc_multi", "oc_multi", request, nil)
+}
+
+///////////////////////////////////////
+// Requests
+///////////////////////////////////////
+
+// orderRequest is an order request awaiting its notification.
+type orderRequest struct {
+	kind     string                     // Request type without "-req", e.g. "on"
+	key      string                     // Correlation key, e.g. "on:<CID>"
+	response chan bitfinex.Notification // Closed if the connection is lost
+}
+
+// request sends [0, kind, null, payload] and waits for the notification
+// correlated by key, decoding its data into v.
+func (c *Client) request(kind, key string, payload interface{}, v interface{}) (err error) {
+	if c.Paused() {
+		return ErrPaused
+	}
+
+	c.mu.Lock()
+	if _, ok := c.subs[0]; !ok {
+		c.mu.Unlock()
+		return ErrNotAuthenticated
+	}
+	r := &orderRequest{kind: kind, key: key, response: make(chan bitfinex.Notification, 1)}
+	c.requests = append(c.requests, r)
+	c.mu.Unlock()
+
+	err = c.send([]interface{}{0, kind, nil, payload})
+	if err == nil {
+		select {
+		case n, ok := <-r.response:
+			if !ok {
+				return ErrNotConnected
+			}
+			return notificationResult(n, v)
+		case <-time.After(c.Timeout):
+			err = ErrTimeout
+		}
+	}
+
+	// Stop waiting
+	c.mu.Lock()
+	c.removeRequest(r)
+	c.mu.Unlock()
+
+	return
+}
+
+func notificationResult(n bitfinex.Notification, v interface{}) error {
+	if n.Status != "SUCCESS" {
+		return &RequestError{Type: n.Type, Status: n.Status, Text: n.Text}
+	}
+
+	if v == nil {
+		return nil
+	}
+	return bitfinex.DecodeArray(n.Data, v)
+}
+
+// handleNotification passes request notifications to the requests waiting
+// for them, the oldest request first. Notifications without data, e.g. some
+// errors, go to the oldest request of their type.
+func (c *Client) handleNotification(n bitfinex.Notification) {
+	kind := strings.TrimSuffix(n.Type, "-req")
+	key := ""
+	order := bitfinex.Order{}
+
+	switch kind {
+	case "on", "ou", "oc":
+		if n.Data == nil || bitfinex.DecodeArray(n.Data, &order) != nil {
+			break
+		}
+		if kind == "on" {
+			key = "on:" + strconv.Itoa(order.CID)
+		} else {
+			key = kind + ":" + strconv.Itoa(order.ID)
+		}
+
+	case "oc_multi":
+		key = "oc_multi"
+
+	default:
+		return
+	}
+
+	c.mu.Lock()
+	var response chan bitfinex.Notification
+	for _, r := range c.requests {
+		if (key != "" && r.key == key) || (key == "" && r.kind == kind) {
+			response = r.response
+			c.removeRequest(r)
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if response != nil {
+		response <- n
+	}
+}
+
+// removeRequest stops r from waiting, c.mu must be held.
+func (c *Client) removeRequest(r *orderRequest) {
+	for i, waiting := range c.requests {
+		if waiting == r {
+			c.requests = append(c.requests[:i], c.requests[i+1:]...)
+			return
+		}
+	}
+}
+
+// failRequests fails all order requests awaiting notification, as their
+// connection is lost.
+func (c *Client) failRequests() {
+	c.mu.Lock()
+	requests := c.requests
+	c.requests = nil
+	c.mu.Unlock()
+
+	for _, r := range requests {
+		close(r.response)
+	}
+}
+
+// newCID returns a new client order ID, unique for the client: the
+// millisecond timestamp of the first order, counting up.
+func (c *Client) newCID() int {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	atomic.CompareAndSwapInt64(&c.lastCID, 0, now)
+
+	return int(atomic.AddInt64(&c.lastCID, 1))
+}
@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"testing"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+func TestOrders(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		if msg["event"] == "auth" {
+			conn.authenticated(msg, "secret")
+		}
+	})
+	defer s.Close()
+
+	s.input = func(conn *testConn, msg []interface{}) {
+		payload := msg[3].(map[string]interface{})
+
+		switch msg[1] {
+		case "on":
+			cid := payload["cid"]
+			if payload["amount"] == "100" {
+				conn.send([]interface{}{0, "n", []interface{}{1500000000000, "on-req", nil, nil,
+					[]interface{}{nil, nil, cid, payload["symbol"], nil, nil, payload["amount"], payload["amount"], payload["type"]},
+					nil, "ERROR", "Invalid order: not enough exchange balance"}})
+				return
+			}
+
+			order := []interface{}{10, 0, cid, payload["symbol"], 1500000000000, 1500000000000, payload["amount"], payload["amount"], payload["type"], nil, nil, nil, 0, "ACTIVE", nil, nil, payload["price"]}
+			conn.send([]interface{}{0, "on", order})
+			conn.send([]interface{}{0, "n", []interface{}{1500000000000, "on-req", nil, nil, order, nil, "SUCCESS", "Submitting"}})
+
+		case "ou":
+			if payload["id"] == 99.0 { // Errors may come without the order
+				conn.send([]interface{}{0, "n", []interface{}{1500000000001, "ou-req", nil, nil, nil, nil, "ERROR", "Order not found"}})
+				return
+			}
+			order := []interface{}{payload["id"], 0, 5, "tBTCUSD", 1500000000000, 1500000000001, 0.5, 0.5, "EXCHANGE LIMIT", nil, nil, nil, 0, "ACTIVE", nil, nil, payload["price"]}
+			conn.send([]interface{}{0, "n", []interface{}{1500000000001, "ou-req", nil, nil, order, nil, "SUCCESS", "Updating"}})
+
+		case "oc":
+			if payload["id"] == 99.0 { // Lost before the notification
+				conn.Close()
+				return
+			}
+			order := []interface{}{payload["id"], 0, 5, "tBTCUSD", 1500000000000, 1500000000002, 0.5, 0.5, "EXCHANGE LIMIT", nil, nil, nil, 0, "ACTIVE", nil, nil, 7000}
+			conn.send([]interface{}{0, "n", []interface{}{1500000000002, "oc-req", nil, nil, order, nil, "SUCCESS", "Cancelling"}})
+
+		case "oc_multi":
+			conn.send([]interface{}{0, "n", []interface{}{1500000000003, "oc_multi-req", nil, nil, []interface{}{}, nil, "SUCCESS", "Cancelling"}})
+		}
+	}
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	order := bitfinex.OrderRequest{Type: "exchange limit", Symbol: "BTCUSD", Amount: 0.5, Price: 7000}
+	if _, err := c.SubmitOrder(order); err != ErrNotAuthenticated {
+		t.Errorf("Failed: expected ErrNotAuthenticated, got %v", err)
+	}
+
+	account, err := c.Authenticate(AuthRequest{APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	submitted, err := c.SubmitOrder(order)
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	if submitted.ID != 10 || submitted.CID == 0 || submitted.Symbol != "tBTCUSD" || submitted.Type != "EXCHANGE LIMIT" || submitted.Price != 7000 {
+		t.Errorf("Failed: unexpected order %+v", submitted)
+	}
+
+	// Order events and notifications are still sent to the account subscription
+	if e, ok := receive(t, account.Events).(OrderEvent); !ok || e.Type != "on" {
+		t.Errorf("Failed: unexpected account event %+v", e)
+	}
+	if n, ok := receive(t, account.Events).(NotificationEvent); !ok || n.Notification.Type != "on-req" {
+		t.Errorf("Failed: unexpected account event %+v", n)
+	}
+
+	order.Amount = 100
+	order.CID = 12345
+	_, err = c.SubmitOrder(order)
+	requestErr, ok := err.(*RequestError)
+	if !ok || requestErr.Type != "on-req" || requestErr.Status != "ERROR" || err.Error() != "API: Invalid order: not enough exchange balance" {
+		t.Errorf("Failed: expected request error, got %v", err)
+	}
+
+	updated, err := c.UpdateOrder(OrderUpdate{ID: 10, Price: 7100})
+	if err != nil || updated.ID != 10 || updated.Price != 7100 {
+		t.Errorf("Failed: unexpected update %+v (%v)", updated, err)
+	}
+
+	cancelled, err := c.CancelOrder(10)
+	if err != nil || cancelled.ID != 10 {
+		t.Errorf("Failed: unexpected cancel %+v (%v)", cancelled, err)
+	}
+
+	if err = c.CancelOrders(10, 11); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+
+	_, err = c.UpdateOrder(OrderUpdate{ID: 99, Price: 7100})
+	if requestErr, ok := err.(*RequestError); !ok || requestErr.Type != "ou-req" || requestErr.Text != "Order not found" {
+		t.Errorf("Failed: expected request error, got %v", err)
+	}
+
+	if _, err = c.CancelOrder(99); err != ErrNotConnected {
+		t.Errorf("Failed: expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestNewCID(t *testing.T) {
+	c := New()
+	cids := make(chan int, 1000)
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				cids <- c.newCID()
+			}
+		}()
+	}
+
+	seen := map[int]bool{}
+	for i := 0; i < 1000; i++ {
+		cid := <-cids
+		if seen[cid] {
+			t.Fatalf("Failed: duplicate CID %d", cid)
+		}
+		seen[cid] = true
+	}
+}
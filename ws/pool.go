@@ -0,0 +1,446 @@
+package ws
+
+import (
+	"sync"
+	"time"
+
+	bitfinex "github.com/eAndrius/bitfinex-go"
+)
+
+// ChannelLimit is the number of channels the API allows per connection.
+const ChannelLimit = 25
+
+// Pool shards subscriptions to public channels across as many connections as
+// needed, presenting them as one client. Connections are opened as channels
+// are subscribed to. The subscriptions of a lost connection are moved to the
+// other connections, or new ones, ResyncEvent is sent before their new snapshot.
+type Pool struct {
+	URL        string        // WebSocket API URL, URL by default
+	Timeout    time.Duration // How long requests wait for a response, 10 seconds by default
+	Limit      int           // Channels per connection, ChannelLimit by default
+	Flags      int           // Flags enabled on every connection, e.g. CHECKSUM, SEQ_ALL by default
+	Backoff    time.Duration // Delay before moving subscriptions again after a failed attempt, doubled after every failed attempt, 1 second by default
+	MaxBackoff time.Duration // Maximum delay between attempts, 1 minute by default
+
+	// Events receives connection level events of all connections, InfoEvent,
+	// ErrorEvent, DisconnectEvent and ReconnectEvent, sent once the
+	// subscriptions of a lost connection are moved. Events which are not
+	// received in time are dropped.
+	Events <-chan interface{}
+
+	events  chan interface{}
+	mu      sync.Mutex
+	load    map[*Client]int // Channels subscribed or being subscribed to by connection
+	subs    map[*Subscription]bool
+	stop    chan struct{} // Closed by Close to stop moving subscriptions
+	closing bool
+}
+
+// pooled is the state of a Pool subscription.
+type pooled struct {
+	pool      *Pool
+	inner     *Subscription // Subscription on the connection carrying the channel, nil while moving
+	client    *Client
+	cancelled bool // Unsubscribed
+	ending    bool
+
+	sendMu sync.Mutex // Held while delivering events, so Events is not closed meanwhile
+	ended  bool
+}
+
+// NewPool returns a new pool of Bitfinex WebSocket API connections
+func NewPool() (p *Pool) {
+	events := make(chan interface{}, 64)
+
+	p = &Pool{
+		URL:        URL,
+		Timeout:    10 * time.Second,
+		Limit:      ChannelLimit,
+		Flags:      SEQ_ALL,
+		Backoff:    time.Second,
+		MaxBackoff: time.Minute,
+		Events:     events,
+		events:     events,
+		load:       make(map[*Client]int),
+		subs:       make(map[*Subscription]bool),
+		stop:       make(chan struct{}),
+	}
+	return p
+}
+
+// Close closes all connections, closing the Events channels of all subscriptions.
+func (p *Pool) Close() (err error) {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return
+	}
+	p.closing = true
+	close(p.stop)
+
+	clients := []*Client{}
+	for c := range p.load {
+		clients = append(clients, c)
+	}
+	subs := []*Subscription{}
+	for sub := range p.subs {
+		subs = append(subs, sub)
+	}
+	p.subs = make(map[*Subscription]bool)
+	p.mu.Unlock()
+
+	for _, c := range clients {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	for _, sub := range subs {
+		sub.pooled.end(sub)
+	}
+	return
+}
+
+// Connections returns the number of open connections.
+func (p *Pool) Connections() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.load)
+}
+
+// Subscribe subscribes to a channel on the least loaded connection, opening
+// a new one if all are full, and waits for the API to confirm the subscription.
+func (p *Pool) Subscribe(request SubscribeRequest) (sub *Subscription, err error) {
+	if request.Symbol != "" {
+		request.Symbol = normalizeSymbol(request.Symbol)
+	}
+
+	sub = &Subscription{
+		Request: request,
+		closed:  make(chan struct{}),
+	}
+	sub.pooled = &pooled{pool: p}
+	if request.Handler == nil {
+		sub.events = make(chan interface{}, 256)
+		sub.Events = sub.events
+	}
+
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return nil, ErrNotConnected
+	}
+	p.subs[sub] = true
+	p.mu.Unlock()
+
+	err = p.place(sub)
+	if err != nil {
+		p.mu.Lock()
+		delete(p.subs, sub)
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	return
+}
+
+// SubscribeTicker subscribes to the ticker of a trading or funding symbol.
+func (p *Pool) SubscribeTicker(symbol string) (*Subscription, error) {
+	return p.Subscribe(SubscribeRequest{Channel: TICKER, Symbol: symbol})
+}
+
+// SubscribeTrades subscribes to the trades of a trading or funding symbol.
+func (p *Pool) SubscribeTrades(symbol string) (*Subscription, error) {
+	return p.Subscribe(SubscribeRequest{Channel: TRADES, Symbol: symbol})
+}
+
+// SubscribeBook subscribes to the order book of a trading symbol or the
+// funding book of a funding symbol.
+func (p *Pool) SubscribeBook(symbol string, precision bitfinex.Precision, frequency Frequency, length int) (*Subscription, error) {
+	return p.Subscribe(SubscribeRequest{Channel: BOOK, Symbol: symbol, Precision: precision, Frequency: frequency, Length: length})
+}
+
+// SubscribeCandles subscribes to the candles of a trading symbol, or funding
+// symbol with period, e.g. "fUSD:p30".
+func (p *Pool) SubscribeCandles(symbol string, timeframe bitfinex.Timeframe) (*Subscription, error) {
+	if !isFundingSymbol(symbol) {
+		symbol = normalizeSymbol(symbol)
+	}
+	return p.Subscribe(SubscribeRequest{Channel: CANDLES, Key: "trade:" + string(timeframe) + ":" + symbol})
+}
+
+func (p *Pool) unsubscribe(sub *Subscription) (err error) {
+	p.mu.Lock()
+	if sub.pooled.cancelled {
+		p.mu.Unlock()
+		return
+	}
+	sub.pooled.cancelled = true
+	inner, c := sub.pooled.inner, sub.pooled.client
+	delete(p.subs, sub)
+	p.mu.Unlock()
+
+	// Ended first, so an undrained subscription does not block the read loop
+	// of the connection while unsubscribing
+	sub.pooled.end(sub)
+
+	// While moving, the channel is unsubscribed from once moved
+	if inner != nil {
+		err = inner.Unsubscribe()
+		p.release(c)
+	}
+
+	return
+}
+
+///////////////////////////////////////
+// Connection handling
+///////////////////////////////////////
+
+// place subscribes to the channel of sub on the least loaded connection.
+func (p *Pool) place(sub *Subscription) (err error) {
+	c, err := p.reserve()
+	if err != nil {
+		return
+	}
+
+	request := sub.Request
+	request.Handler = func(event interface{}) { sub.pooled.deliver(sub, event) }
+
+	inner, err := c.Subscribe(request)
+	if err != nil {
+		p.release(c)
+		return
+	}
+
+	p.mu.Lock()
+	if _, ok := p.load[c]; !ok { // Lost meanwhile, not moved with the others
+		p.mu.Unlock()
+		return ErrNotConnected
+	}
+	if sub.pooled.cancelled || p.closing {
+		if p.closing {
+			err = ErrNotConnected
+		}
+		p.mu.Unlock()
+		inner.Unsubscribe()
+		p.release(c)
+		sub.pooled.end(sub)
+		return
+	}
+	sub.pooled.inner = inner
+	sub.pooled.client = c
+	p.mu.Unlock()
+
+	return
+}
+
+// reserve returns the least loaded connection with a free channel, opening a
+// new one if all are full, and counts the channel about to be subscribed to.
+func (p *Pool) reserve() (c *Client, err error) {
+	p.mu.Lock()
+	for client, load := range p.load {
+		if load < p.Limit && (c == nil || load < p.load[c]) {
+			c = client
+		}
+	}
+	if c != nil {
+		p.load[c]++
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	c = New()
+	c.URL = p.URL
+	c.Timeout = p.Timeout
+	c.Flags = p.Flags
+
+	err = c.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		c.Close()
+		return nil, ErrNotConnected
+	}
+	p.load[c] = 1
+	p.mu.Unlock()
+
+	go p.watch(c)
+	return
+}
+
+// release stops counting a channel of the connection, closing the
+// connection once it carries no channels.
+func (p *Pool) release(c *Client) {
+	p.mu.Lock()
+	load, ok := p.load[c]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	if load > 1 {
+		p.load[c]--
+		p.mu.Unlock()
+		return
+	}
+	delete(p.load, c) // Forgotten, so closing it moves nothing
+	p.mu.Unlock()
+
+	c.Close()
+}
+
+// watch forwards the connection level events of the connection and moves its
+// subscriptions once it is lost. Connections are closed, to be replaced, when
+// the API asks to reconnect or messages were lost.
+func (p *Pool) watch(c *Client) {
+	done := c.Done()
+
+	for {
+		select {
+		case e := <-c.Events:
+			info, ok := e.(InfoEvent)
+			_, gap := e.(GapEvent)
+			if gap || ok && (info.Code == RECONNECT || info.Code == RESUME) {
+				c.mu.Lock()
+				conn := c.conn
+				c.mu.Unlock()
+				if conn != nil {
+					conn.Close()
+				}
+			}
+			p.emit(e)
+
+		case <-done:
+			p.lost(c)
+			return
+		}
+	}
+}
+
+// lost moves the subscriptions of the lost connection.
+func (p *Pool) lost(c *Client) {
+	p.mu.Lock()
+	_, ok := p.load[c]
+	delete(p.load, c)
+	if !ok || p.closing { // Closed as idle or by Close
+		p.mu.Unlock()
+		return
+	}
+
+	moving := []*Subscription{}
+	for sub := range p.subs {
+		if sub.pooled.client == c && !sub.pooled.cancelled {
+			sub.pooled.inner = nil
+			sub.pooled.client = nil
+			moving = append(moving, sub)
+		}
+	}
+	p.mu.Unlock()
+
+	p.emit(DisconnectEvent{Err: c.Err()})
+
+	if len(moving) == 0 {
+		return
+	}
+
+	for _, sub := range moving {
+		sub.pooled.deliver(sub, ResyncEvent{})
+	}
+
+	go p.move(moving)
+}
+
+// move places subs on the remaining connections, retrying with backoff.
+func (p *Pool) move(subs []*Subscription) {
+	backoff := p.Backoff
+
+	for attempts := 1; ; attempts++ {
+		failed := []*Subscription{}
+		for _, sub := range subs {
+			p.mu.Lock()
+			cancelled, closing := sub.pooled.cancelled, p.closing
+			p.mu.Unlock()
+
+			if closing {
+				return
+			}
+			if cancelled {
+				sub.pooled.end(sub)
+				continue
+			}
+
+			if p.place(sub) != nil {
+				failed = append(failed, sub)
+			}
+		}
+
+		if len(failed) == 0 {
+			p.emit(ReconnectEvent{Attempts: attempts})
+			return
+		}
+		subs = failed
+
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// emit sends a connection level event, dropping it if Events is full.
+func (p *Pool) emit(event interface{}) {
+	select {
+	case p.events <- event:
+	default:
+	}
+}
+
+// deliver sends an event to the subscription, unless it ended.
+func (s *pooled) deliver(sub *Subscription, event interface{}) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	if sub.Request.Handler != nil {
+		sub.Request.Handler(event)
+		return
+	}
+
+	select {
+	case sub.events <- event:
+	case <-sub.closed: // Ending
+	}
+}
+
+// end closes the subscription once.
+func (s *pooled) end(sub *Subscription) {
+	s.pool.mu.Lock()
+	if s.ending {
+		s.pool.mu.Unlock()
+		return
+	}
+	s.ending = true
+	close(sub.closed) // Stops blocked deliveries
+	s.pool.mu.Unlock()
+
+	s.sendMu.Lock()
+	s.ended = true
+	if sub.events != nil {
+		close(sub.events)
+	}
+	s.sendMu.Unlock()
+}
@@ -0,0 +1,197 @@
+package ws
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	var mu sync.Mutex
+	channels := map[*testConn]int{}
+
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "subscribe":
+			mu.Lock()
+			channels[conn]++
+			mu.Unlock()
+
+			chanID := conn.subscribed(msg)
+			conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+		case "unsubscribe":
+			mu.Lock()
+			channels[conn]--
+			mu.Unlock()
+
+			conn.send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+		}
+	})
+	defer s.Close()
+
+	p := NewPool()
+	p.URL = s.URL
+	p.Flags = 0
+	p.Timeout = time.Second
+	p.Limit = 2
+	p.Backoff = 10 * time.Millisecond
+	defer p.Close()
+
+	symbols := []string{"tBTCUSD", "tETHUSD", "tLTCUSD", "tXRPUSD", "fUSD"}
+	subs := []*Subscription{}
+	for _, symbol := range symbols {
+		sub, err := p.SubscribeTicker(symbol)
+		if err != nil {
+			t.Fatal("Failed: " + err.Error())
+		}
+		receive(t, sub.Events)
+		subs = append(subs, sub)
+	}
+
+	if n := p.Connections(); n != 3 {
+		t.Errorf("Failed: expected 3 connections, got %d", n)
+	}
+
+	// The first connection carries the first two channels
+	s.mu.Lock()
+	s.conns[0].Close()
+	s.mu.Unlock()
+
+	receiveType(t, p.Events, func(e interface{}) bool { _, ok := e.(DisconnectEvent); return ok })
+	receiveType(t, p.Events, func(e interface{}) bool { _, ok := e.(ReconnectEvent); return ok })
+
+	for _, sub := range subs[:2] {
+		if _, ok := receive(t, sub.Events).(ResyncEvent); !ok {
+			t.Errorf("Failed: expected %s resync", sub.Request.Symbol)
+		}
+		if ticker, ok := receive(t, sub.Events).(TickerEvent); !ok || ticker.Ticker.Symbol != sub.Request.Symbol {
+			t.Errorf("Failed: unexpected %s event %+v", sub.Request.Symbol, ticker)
+		}
+	}
+
+	if n := p.Connections(); n != 3 {
+		t.Errorf("Failed: expected 3 connections after moving, got %d", n)
+	}
+
+	mu.Lock()
+	for conn, n := range channels {
+		if conn != s.conns[0] && n > 2 {
+			t.Errorf("Failed: %d channels on one connection", n)
+		}
+	}
+	mu.Unlock()
+
+	if err := subs[0].Unsubscribe(); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+	if _, ok := <-subs[0].Events; ok {
+		t.Error("Failed: expected events to be closed")
+	}
+
+	// The freed channel is reused
+	sub, err := p.SubscribeTicker("tEOSUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	receive(t, sub.Events)
+
+	if n := p.Connections(); n != 3 {
+		t.Errorf("Failed: expected 3 connections, got %d", n)
+	}
+
+	p.Close()
+
+	for _, sub := range subs[1:] {
+		for range sub.Events {
+			// Drained until closed
+		}
+	}
+}
+
+func TestPoolUndrained(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "subscribe":
+			chanID := conn.subscribed(msg)
+			n := 1
+			if msg["symbol"] == "tBTCUSD" {
+				n = 300
+			}
+			for i := 0; i < n; i++ {
+				conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+			}
+		case "unsubscribe":
+			conn.send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+		}
+	})
+	defer s.Close()
+
+	p := NewPool()
+	p.URL = s.URL
+	p.Flags = 0
+	p.Timeout = time.Second
+	defer p.Close()
+
+	undrained, err := p.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	time.Sleep(100 * time.Millisecond) // Events fill up
+
+	if err = undrained.Unsubscribe(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	// Other channels of the connection are not blocked
+	sub, err := p.SubscribeTicker("tETHUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	receive(t, sub.Events)
+}
+
+func TestPoolIdle(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "subscribe":
+			conn.subscribed(msg)
+		case "unsubscribe":
+			conn.send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+		}
+	})
+	defer s.Close()
+
+	p := NewPool()
+	p.URL = s.URL
+	p.Flags = 0
+	p.Timeout = time.Second
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		sub, err := p.SubscribeTicker("tBTCUSD")
+		if err != nil {
+			t.Fatal("Failed: " + err.Error())
+		}
+		if err = sub.Unsubscribe(); err != nil {
+			t.Fatal("Failed: " + err.Error())
+		}
+
+		// Empty connections are closed
+		if n := p.Connections(); n != 0 {
+			t.Errorf("Failed: expected no connections, got %d", n)
+		}
+	}
+
+	// Closing idle connections does not move anything
+	timeout := time.After(100 * time.Millisecond)
+	for {
+		select {
+		case e := <-p.Events:
+			if _, ok := e.(InfoEvent); !ok {
+				t.Errorf("Failed: unexpected event %+v", e)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package ws
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Info codes
+const (
+	// RECONNECT asks clients to reconnect, e.g. before a server restart
+	RECONNECT = 20051
+	// MAINTENANCE is sent when the platform enters maintenance, activity
+	// should be paused until RESUME
+	MAINTENANCE = 20060
+	// RESUME is sent when the platform leaves maintenance, channels should be
+	// resubscribed
+	RESUME = 20061
+)
+
+// Paused returns true while the platform is in maintenance. Subscribe returns
+// ErrPaused while paused.
+func (c *Client) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// handleInfo pauses and resumes on maintenance info events and reconnects
+// when asked to.
+func (c *Client) handleInfo(info InfoEvent) {
+	switch {
+	case info.Version != 0: // Sent on connect
+		c.mu.Lock()
+		c.paused = info.Platform.Status == 0
+		c.mu.Unlock()
+
+	case info.Code == RECONNECT:
+		c.drop()
+
+	case info.Code == MAINTENANCE:
+		c.mu.Lock()
+		c.paused = true
+		c.mu.Unlock()
+
+	case info.Code == RESUME:
+		c.mu.Lock()
+		c.paused = false
+		c.mu.Unlock()
+
+		// Reconnecting resubscribes all channels
+		c.drop()
+	}
+}
+
+// drop closes the connection if Reconnect is enabled, so the client reconnects.
+func (c *Client) drop() {
+	c.mu.Lock()
+	conn, reconnect := c.conn, c.Reconnect
+	c.mu.Unlock()
+
+	if reconnect && conn != nil {
+		conn.Close()
+	}
+}
+
+// reconnect reconnects with backoff and restores subs, closing done if the
+// client is closed meanwhile.
+func (c *Client) reconnect(subs []*Subscription, done chan struct{}) {
+	c.mu.Lock()
+	stop, backoff := c.stop, c.Backoff
+	c.mu.Unlock()
+
+	for attempts := 1; ; attempts++ {
+		select {
+		case <-stop:
+			end(subs, done)
+			return
+		case <-time.After(backoff):
+		}
+
+		conn, _, err := websocket.DefaultDialer.Dial(c.URL, nil)
+		if err != nil {
+			backoff *= 2
+			if backoff > c.MaxBackoff {
+				backoff = c.MaxBackoff
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		if c.closing {
+			c.mu.Unlock()
+			conn.Close()
+			end(subs, done)
+			return
+		}
+		c.conn = conn
+		c.err = nil
+		c.paused = false
+		msgs := c.restore(subs)
+		c.mu.Unlock()
+
+		go c.listen(conn, done)
+
+		for _, msg := range msgs {
+			if c.send(msg) != nil {
+				break // Lost again, the subscriptions are carried over to the next reconnection
+			}
+		}
+
+		c.emit(ReconnectEvent{Attempts: attempts})
+		return
+	}
+}
+
+// restore registers subs as resubscribing and returns the messages which
+// repeat the flags, authentication and subscriptions. It must be called with
+// c.mu held, before the read loop starts, so a lost connection carries the
+// subscriptions over to the next reconnection.
+func (c *Client) restore(subs []*Subscription) (msgs []interface{}) {
+	if c.flags != 0 {
+		msgs = append(msgs, map[string]interface{}{
+			"event": "conf",
+			"flags": c.flags,
+		})
+	}
+
+	subscribe := []interface{}{}
+	for _, sub := range subs {
+		if sub.cancelled || (sub.Request.Channel == ACCOUNT && c.authReq == nil) {
+			sub.end()
+			continue
+		}
+
+		sub.resync = true
+
+		if sub.Request.Channel == ACCOUNT {
+			c.auth = sub
+			msgs = append(msgs, authMessage(*c.authReq))
+			continue
+		}
+
+		c.nextID++
+		sub.subID = strconv.FormatInt(c.nextID, 10)
+		c.pending[sub.subID] = sub
+		subscribe = append(subscribe, subscribeMessage(sub))
+	}
+
+	return append(msgs, subscribe...)
+}
+
+func end(subs []*Subscription, done chan struct{}) {
+	for _, sub := range subs {
+		sub.end()
+	}
+	close(done)
+}
@@ -0,0 +1,206 @@
+package ws
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiveType receives events until one matching match is received.
+func receiveType(t *testing.T, events <-chan interface{}, match func(e interface{}) bool) interface{} {
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("Failed: events closed")
+			}
+			if match(e) {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("Failed: expected event not received")
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	var mu sync.Mutex
+	received := []map[string]interface{}{}
+
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		mu.Lock()
+		received = append(received, msg)
+		mu.Unlock()
+
+		switch msg["event"] {
+		case "conf":
+			conn.send(map[string]interface{}{"event": "conf", "status": "OK", "flags": msg["flags"]})
+		case "auth":
+			conn.authenticated(msg, "secret")
+			conn.sendRaw(`[0,"ws",[]]`)
+		case "subscribe":
+			chanID := conn.subscribed(msg)
+			conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+		case "unsubscribe":
+			conn.send(map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg["chanId"]})
+		}
+	})
+	defer s.Close()
+
+	c := New()
+	c.URL = s.URL
+	c.Timeout = time.Second
+	c.Flags = 0
+	c.Reconnect = true
+	c.Backoff = 10 * time.Millisecond
+
+	if err := c.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	defer c.Close()
+
+	if err := c.Configure(CHECKSUM); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	account, err := c.Authenticate(AuthRequest{APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	receive(t, account.Events)
+
+	ticker, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	receive(t, ticker.Events)
+
+	mu.Lock()
+	received = nil
+	mu.Unlock()
+
+	s.drop()
+
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(DisconnectEvent); return ok })
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(ReconnectEvent); return ok })
+
+	if _, ok := receive(t, ticker.Events).(ResyncEvent); !ok {
+		t.Error("Failed: expected ticker resync")
+	}
+	if _, ok := receive(t, ticker.Events).(TickerEvent); !ok {
+		t.Error("Failed: expected ticker after resync")
+	}
+
+	if _, ok := receive(t, account.Events).(ResyncEvent); !ok {
+		t.Error("Failed: expected account resync")
+	}
+	if _, ok := receive(t, account.Events).(WalletsSnapshot); !ok {
+		t.Error("Failed: expected wallets after resync")
+	}
+
+	mu.Lock()
+	events := []interface{}{}
+	for _, msg := range received {
+		events = append(events, msg["event"])
+	}
+	mu.Unlock()
+
+	if len(events) != 3 || events[0] != "conf" || events[1] != "auth" || events[2] != "subscribe" {
+		t.Errorf("Failed: unexpected replay %v", events)
+	}
+
+	// Unsubscribing still works on the new channel
+	if err = ticker.Unsubscribe(); err != nil {
+		t.Error("Failed: " + err.Error())
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		chanID := conn.subscribed(msg)
+		conn.send([]interface{}{chanID, []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}})
+	})
+	defer s.Close()
+
+	c := New()
+	c.URL = s.URL
+	c.Timeout = time.Second
+	c.Flags = 0
+	c.Reconnect = true
+	c.Backoff = 10 * time.Millisecond
+
+	if err := c.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	defer c.Close()
+
+	ticker, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	receive(t, ticker.Events)
+
+	s.broadcast(map[string]interface{}{"event": "info", "code": MAINTENANCE, "msg": "Maintenance"})
+	receiveType(t, c.Events, func(e interface{}) bool { info, ok := e.(InfoEvent); return ok && info.Code == MAINTENANCE })
+
+	if !c.Paused() {
+		t.Error("Failed: expected client to be paused")
+	}
+	if _, err = c.SubscribeTicker("tETHUSD"); err != ErrPaused {
+		t.Errorf("Failed: expected ErrPaused, got %v", err)
+	}
+
+	s.broadcast(map[string]interface{}{"event": "info", "code": RESUME, "msg": "Resumed"})
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(ReconnectEvent); return ok })
+
+	if c.Paused() {
+		t.Error("Failed: expected client to be resumed")
+	}
+	if _, ok := receive(t, ticker.Events).(ResyncEvent); !ok {
+		t.Error("Failed: expected ticker resync")
+	}
+}
+
+func TestReconnectClose(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		conn.subscribed(msg)
+	})
+
+	c := New()
+	c.URL = s.URL
+	c.Timeout = time.Second
+	c.Flags = 0
+	c.Reconnect = true
+	c.Backoff = 10 * time.Millisecond
+
+	if err := c.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	ticker, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	// Info code 20051 asks to reconnect
+	s.broadcast(map[string]interface{}{"event": "info", "code": RECONNECT, "msg": "Restarting"})
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(ReconnectEvent); return ok })
+
+	// Closing while reconnecting stops reconnecting
+	s.drop()
+	s.Close()
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(DisconnectEvent); return ok })
+
+	c.Close()
+
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Failed: client not closed")
+	}
+
+	for range ticker.Events {
+		// Drained until closed
+	}
+}
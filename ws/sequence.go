@@ -0,0 +1,103 @@
+package ws
+
+// sequence strips the sequence numbers appended to channel messages if
+// SEQ_ALL is enabled, verifying them. Public messages end with the public
+// sequence number, [CHANNEL_ID, ..., SEQ], and account messages with both,
+// [0, TYPE, DATA, SEQ, AUTH_SEQ], except notifications of requests, which
+// are not part of the public sequence and carry only AUTH_SEQ:
+//
+//	[0, "n", [MTS, "on-req", null, null, [ORDER], null, "SUCCESS", "Submitting 1 orders."], AUTH_SEQ]
+//
+// Heartbeats of the account channel, [0, "hb", SEQ], carry only SEQ.
+func (c *Client) sequence(raw []interface{}) []interface{} {
+	c.mu.Lock()
+	enabled := c.flags&SEQ_ALL != 0
+	c.mu.Unlock()
+
+	if !enabled || len(raw) < 3 {
+		return raw
+	}
+
+	chanID, err := toInt64(raw[0])
+	if err != nil {
+		return raw
+	}
+
+	if chanID == 0 && raw[1] != "hb" {
+		authSeq, err := toInt64(raw[len(raw)-1])
+		if err != nil {
+			return raw
+		}
+		raw = raw[:len(raw)-1]
+
+		if !isRequestNotification(raw) {
+			raw = c.publicSequence(raw)
+		}
+
+		c.verify(true, authSeq)
+		return raw
+	}
+
+	return c.publicSequence(raw)
+}
+
+// publicSequence strips and verifies the public sequence number of raw.
+func (c *Client) publicSequence(raw []interface{}) []interface{} {
+	seq, err := toInt64(raw[len(raw)-1])
+	if err != nil {
+		return raw
+	}
+
+	c.verify(false, seq)
+	return raw[:len(raw)-1]
+}
+
+// verify checks that seq follows the last sequence number, sending GapEvent
+// and closing the connection if it does not: the client reconnects to resync
+// with Reconnect, otherwise the connection is closed for good with ErrGap.
+func (c *Client) verify(authenticated bool, seq int64) {
+	c.mu.Lock()
+	last := &c.seq
+	if authenticated {
+		last = &c.authSeq
+	}
+
+	if authenticated && seq == 0 { // Not synced yet
+		c.mu.Unlock()
+		return
+	}
+
+	expected := *last + 1
+	gap := *last != 0 && seq != expected
+	*last = seq
+	c.mu.Unlock()
+
+	if gap {
+		c.emit(GapEvent{Authenticated: authenticated, Expected: expected, Received: seq})
+
+		c.mu.Lock()
+		conn := c.conn
+		c.gap = !c.Reconnect
+		c.mu.Unlock()
+
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
+
+// isRequestNotification returns true for [0, "n", [MTS, "on-req", ...]]
+// notifications of requests.
+func isRequestNotification(raw []interface{}) bool {
+	if len(raw) < 3 || raw[1] != "n" {
+		return false
+	}
+
+	n, ok := raw[2].([]interface{})
+	if !ok || len(n) < 2 {
+		return false
+	}
+
+	kind, _ := n[1].(string)
+	return len(kind) > 4 && kind[len(kind)-4:] == "-req"
+}
@@ -0,0 +1,215 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequence(t *testing.T) {
+	var mu sync.Mutex
+	connections, confs := 0, 0
+
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "conf":
+			if msg["flags"] == float64(SEQ_ALL) {
+				mu.Lock()
+				confs++
+				mu.Unlock()
+			}
+			conn.send(map[string]interface{}{"event": "conf", "status": "OK", "flags": msg["flags"]})
+		case "subscribe":
+			mu.Lock()
+			connections++
+			first := connections == 1
+			mu.Unlock()
+
+			chanID := conn.subscribed(msg)
+			ticker := []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}
+			conn.send([]interface{}{chanID, ticker, 1})
+			conn.send([]interface{}{chanID, "hb", 2})
+			if first {
+				conn.send([]interface{}{chanID, ticker, 4}) // 3 is lost
+			}
+		}
+	})
+	defer s.Close()
+
+	c := New()
+	c.URL = s.URL
+	c.Timeout = time.Second
+	c.Reconnect = true
+	c.Backoff = 10 * time.Millisecond
+
+	if err := c.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	defer c.Close() // SEQ_ALL is enabled by default
+
+	sub, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	if ticker, ok := receive(t, sub.Events).(TickerEvent); !ok || ticker.Ticker.Low != 6900 {
+		t.Errorf("Failed: unexpected ticker %+v", ticker)
+	}
+
+	e := receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(GapEvent); return ok })
+	if gap := e.(GapEvent); gap.Authenticated || gap.Expected != 3 || gap.Received != 4 {
+		t.Errorf("Failed: unexpected gap %+v", gap)
+	}
+
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(ReconnectEvent); return ok })
+
+	// The message received after the gap is still delivered
+	receive(t, sub.Events)
+	if _, ok := receive(t, sub.Events).(ResyncEvent); !ok {
+		t.Error("Failed: expected resync")
+	}
+	receive(t, sub.Events)
+
+	// Sequence numbers start over on the new connection
+	select {
+	case e := <-c.Events:
+		if _, ok := e.(GapEvent); ok {
+			t.Errorf("Failed: unexpected gap %+v", e)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// SEQ_ALL is enabled again on the new connection
+	mu.Lock()
+	if confs != 2 {
+		t.Errorf("Failed: expected SEQ_ALL on 2 connections, got %d", confs)
+	}
+	mu.Unlock()
+}
+
+func TestSequenceClose(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "conf":
+			conn.send(map[string]interface{}{"event": "conf", "status": "OK", "flags": msg["flags"]})
+		case "subscribe":
+			chanID := conn.subscribed(msg)
+			ticker := []interface{}{7000, 10, 7001, 12, -50, -0.01, 7000.5, 1000, 7100, 6900}
+			conn.send([]interface{}{chanID, ticker, 1})
+			conn.send([]interface{}{chanID, ticker, 3}) // 2 is lost
+		}
+	})
+	defer s.Close()
+
+	c := New()
+	c.URL = s.URL
+	c.Timeout = time.Second
+
+	if err := c.Connect(); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+	defer c.Close()
+
+	sub, err := c.SubscribeTicker("tBTCUSD")
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(GapEvent); return ok })
+
+	// Without Reconnect the stale connection is closed
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Failed: connection not closed")
+	}
+	if err := c.Err(); err != ErrGap {
+		t.Errorf("Failed: expected ErrGap, got %v", err)
+	}
+
+	for {
+		select {
+		case _, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Failed: events not closed")
+		}
+	}
+}
+
+func TestSequenceAuthenticated(t *testing.T) {
+	s := newTestServer(t, func(conn *testConn, msg map[string]interface{}) {
+		switch msg["event"] {
+		case "conf":
+			conn.send(map[string]interface{}{"event": "conf", "status": "OK", "flags": msg["flags"]})
+		case "auth":
+			conn.authenticated(msg, "secret")
+			conn.sendRaw(`[0,"ws",[["exchange","BTC",1.5,0,1.5,null]],1,0]`)
+			conn.sendRaw(`[0,"hb",2]`)
+			conn.sendRaw(`[0,"n",[1500000000000,"on-req",null,null,[10,0,5,"tBTCUSD"],null,"SUCCESS","Submitting"],1]`)
+			conn.sendRaw(`[0,"wu",["exchange","BTC",1.5,0,1.25,null],3,2]`)
+			conn.sendRaw(`[0,"wu",["exchange","BTC",1.5,0,1,null],4,4]`) // Authenticated 3 is lost
+		}
+	})
+	defer s.Close()
+
+	c := newTestClient(t, s)
+	defer c.Close()
+
+	if err := c.Configure(SEQ_ALL); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	sub, err := c.Authenticate(AuthRequest{APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	if wallets, ok := receive(t, sub.Events).(WalletsSnapshot); !ok || len(wallets.Wallets) != 1 {
+		t.Errorf("Failed: unexpected wallets %+v", wallets)
+	}
+	if n, ok := receive(t, sub.Events).(NotificationEvent); !ok || n.Notification.Status != "SUCCESS" {
+		t.Errorf("Failed: unexpected notification %+v", n)
+	}
+	if wallet, ok := receive(t, sub.Events).(WalletUpdate); !ok || wallet.Wallet.Available != 1.25 {
+		t.Errorf("Failed: unexpected wallet %+v", wallet)
+	}
+	if wallet, ok := receive(t, sub.Events).(WalletUpdate); !ok || wallet.Wallet.Available != 1 {
+		t.Errorf("Failed: unexpected wallet %+v", wallet)
+	}
+
+	e := receiveType(t, c.Events, func(e interface{}) bool { _, ok := e.(GapEvent); return ok })
+	if gap := e.(GapEvent); !gap.Authenticated || gap.Expected != 3 || gap.Received != 4 {
+		t.Errorf("Failed: unexpected gap %+v", gap)
+	}
+}
+
+func TestSequenceRequestNotification(t *testing.T) {
+	c := New()
+	c.flags = SEQ_ALL
+	c.seq, c.authSeq = 7, 3
+
+	// Notifications of requests carry AUTH_SEQ only, unlike other account messages
+	raw := []interface{}{}
+	msg := `[0,"n",[1575289447641,"on-req",null,null,[[37558151258,null,1575289447640,"tBTCUSD",1575289447640,1575289447640,0.001,0.001,"EXCHANGE LIMIT",null,null,null,0,"ACTIVE",null,null,7300,0,0,0,null,null,null,0,0,null,null,null,"API>BFX",null,null,null]],null,"SUCCESS","Submitting 1 orders."],4]`
+	if err := json.Unmarshal([]byte(msg), &raw); err != nil {
+		t.Fatal("Failed: " + err.Error())
+	}
+
+	raw = c.sequence(raw)
+	if len(raw) != 3 || raw[1] != "n" {
+		t.Errorf("Failed: unexpected message %v", raw)
+	}
+	if c.seq != 7 || c.authSeq != 4 {
+		t.Errorf("Failed: unexpected sequence numbers %d, %d", c.seq, c.authSeq)
+	}
+
+	select {
+	case e := <-c.Events:
+		t.Errorf("Failed: unexpected event %+v", e)
+	default:
+	}
+}